@@ -0,0 +1,165 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Caller is a source code location of the log call.
+type Caller struct {
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Function string `json:"function"`
+}
+
+// String returns the caller short file name and line, f.e. "main.go:12".
+func (c *Caller) String() string {
+	return fmt.Sprintf("%s:%d", filepath.Base(c.File), c.Line)
+}
+
+// caller returns the caller of the function which called caller, skipping
+// skip additional frames and the Logger CallerSkip frames. It returns nil if
+// the Logger AddCaller is not set.
+func (l *Logger) caller(skip int) *Caller {
+	if !l.addCaller {
+		return nil
+	}
+
+	// Skip runtime.Callers, this function and the function which called it
+	var pcs [1]uintptr
+	if runtime.Callers(skip+l.callerSkip+2, pcs[:]) == 0 {
+		return nil
+	}
+	return callerFromPC(pcs[0])
+}
+
+// stack returns the stack trace of the function which called stack, skipping
+// skip additional frames and the Logger CallerSkip frames.
+func (l *Logger) stack(skip int) string {
+	frames := userFrames(skip+l.callerSkip+1, func(runtime.Frame) bool { return true })
+	return formatFrames(frames)
+}
+
+// source sets the log entry caller and stack trace. The stack trace is set
+// for the ERROR and above log levels only. The skip is the number of
+// functions between the user code and the function which called source.
+func (l *Logger) source(entry *LogEntry, skip int) {
+	entry.Caller = l.caller(skip + 1)
+	if l.needsStack(entry.Level) {
+		entry.Stack = l.stack(skip + 1)
+	}
+}
+
+// userFrames returns the stack frames of the function which called
+// userFrames, skipping skip additional frames and the frames before the first
+// user frame. It returns nil if the user frame is not found.
+func userFrames(skip int, user func(frame runtime.Frame) bool) (userFrames []runtime.Frame) {
+
+	// Skip runtime.Callers, this function and the function which called it
+	var pcs [64]uintptr
+	n := runtime.Callers(skip+2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+
+	// Skip frames before the first user frame
+	for {
+		frame, more := frames.Next()
+		if len(userFrames) > 0 || user(frame) {
+			userFrames = append(userFrames, frame)
+		}
+		if !more {
+			return
+		}
+	}
+}
+
+// needsStack returns true if the stack trace is added to the log entry with
+// this level.
+func (l *Logger) needsStack(level LogLevel) bool {
+	return l.stackTrace && level.rank() >= LevelError.rank()
+}
+
+// stdlogSource sets the log entry caller and stack trace from the caller of
+// the standard log package function, f.e. log.Println, which called
+// customWriter.Write. The Logger CallerSkip frames are skipped after the
+// standard log package frames. The caller is not changed if the Logger
+// AddCaller is not set or it is not found.
+func (l *Logger) stdlogSource(entry *LogEntry) {
+	needsStack := l.needsStack(entry.Level)
+	if !l.addCaller && !needsStack {
+		return
+	}
+
+	// Get stack starting from the caller of the standard log package
+	frames := userFrames(2, func(frame runtime.Frame) bool {
+		return !strings.HasPrefix(frame.Function, "log.")
+	})
+	frames = frames[min(l.callerSkip, len(frames)):]
+	if len(frames) == 0 {
+		return
+	}
+
+	if l.addCaller {
+		if caller := frameCaller(frames[0]); caller != nil {
+			entry.Caller = caller
+		}
+	}
+	if needsStack {
+		entry.Stack = formatFrames(frames)
+	}
+}
+
+// slogSource sets the log entry caller of the slog record program counter pc
+// and the stack trace starting from this caller. If pc is 0, the stack trace
+// starts from the caller of the log/slog package.
+func (l *Logger) slogSource(entry *LogEntry, pc uintptr) {
+	var function string
+	if pc != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+		function = frame.Function
+		if l.addCaller {
+			entry.Caller = frameCaller(frame)
+		}
+	}
+	if !l.needsStack(entry.Level) {
+		return
+	}
+
+	// Get stack starting from the record caller
+	frames := userFrames(2, func(frame runtime.Frame) bool {
+		if function != "" {
+			return frame.Function == function
+		}
+		return !strings.HasPrefix(frame.Function, "log/slog.")
+	})
+	entry.Stack = formatFrames(frames)
+}
+
+// formatFrames returns the stack trace of the frames formatted like
+// runtime/debug.Stack does.
+func formatFrames(frames []runtime.Frame) string {
+	var b strings.Builder
+	for _, frame := range frames {
+		fmt.Fprintf(&b, "%s\n\t%s:%d\n", frame.Function, frame.File, frame.Line)
+	}
+	return b.String()
+}
+
+// callerFromPC returns the caller by program counter.
+func callerFromPC(pc uintptr) *Caller {
+	frame, _ := runtime.CallersFrames([]uintptr{pc}).Next()
+	return frameCaller(frame)
+}
+
+// frameCaller returns the caller by stack frame.
+func frameCaller(frame runtime.Frame) *Caller {
+	if frame.File == "" {
+		return nil
+	}
+	return &Caller{File: frame.File, Line: frame.Line, Function: frame.Function}
+}
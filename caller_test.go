@@ -0,0 +1,90 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"log"
+	"strings"
+	"testing"
+)
+
+func TestCaller(t *testing.T) {
+
+	sink := &memSink{}
+	l := New(Config{DoesNotShowInitMessage: true, AddCaller: true,
+		Sinks: []Sink{sink},
+	})
+
+	// Log with Logger methods, the standard logger and wrapper function
+	l.Info("info")
+	l.Errorf("errorf %d", 1)
+	std := log.New(l.Writer(), "", log.LstdFlags)
+	std.Println("[WARN] std log")
+	wrapper := func() { l.With(Fields{"k": "v"}).Debug("wrapper") }
+	wrapper()
+	l.Close()
+
+	// Check callers
+	if len(sink.entries) != 4 {
+		t.Fatalf("got %d entries, want 4", len(sink.entries))
+	}
+	for _, entry := range sink.entries {
+		if entry.Caller == nil ||
+			!strings.HasSuffix(entry.Caller.File, "caller_test.go") ||
+			!strings.Contains(entry.Caller.Function, "TestCaller") {
+			t.Fatalf("got wrong caller %+v of entry %q", entry.Caller, entry.Message)
+		}
+	}
+	if sink.entries[2].Level != LevelWarn || sink.entries[2].Message != "std log" {
+		t.Fatalf("got wrong std log entry: %v", sink.entries[2])
+	}
+}
+
+func TestStdlogPrefix(t *testing.T) {
+
+	sink := &memSink{}
+	l := New(Config{DoesNotShowInitMessage: true, Sinks: []Sink{sink}})
+
+	// Log with different standard logger flags
+	log.New(l.Writer(), "", 0).Println("no prefix message")
+	log.New(l.Writer(), "", log.Lshortfile).Println("short file message")
+	log.New(l.Writer(), "", log.LstdFlags|log.Lmicroseconds|log.Lshortfile).
+		Println("[INFO] full prefix message")
+
+	// Message text which looks like prefix parts is not removed
+	log.New(l.Writer(), "", log.LstdFlags).Println("db:5432: connection refused")
+	log.New(l.Writer(), "", 0).Println("db:5432: connection refused")
+	log.New(l.Writer(), "", 0).Println("12:30:00 job started")
+	log.New(l.Writer(), "", log.Lshortfile).Println("12:30:00 job started")
+	l.Close()
+
+	for i, want := range []string{"no prefix message", "short file message",
+		"full prefix message", "db:5432: connection refused",
+		"db:5432: connection refused", "12:30:00 job started",
+		"12:30:00 job started"} {
+		if sink.entries[i].Message != want {
+			t.Fatalf("got message %q, want %q", sink.entries[i].Message, want)
+		}
+	}
+	if c := sink.entries[1].Caller; c == nil || c.File != "caller_test.go" {
+		t.Fatalf("got wrong caller %+v", c)
+	}
+	if c := sink.entries[3].Caller; c != nil && c.File == "db" {
+		t.Fatalf("got caller from message text %+v", c)
+	}
+}
+
+func TestStdlogWriteLen(t *testing.T) {
+
+	// Write returns the full length of the skipped log entries too
+	l := New(Config{DoesNotShowInitMessage: true, MinLevel: LevelError,
+		Sinks: []Sink{&memSink{}}})
+	defer l.Close()
+	for _, p := range []string{"2025/10/19 10:28:50 [DEBUG] skipped\n", "[ERROR] sent\n"} {
+		if n, err := l.Writer().Write([]byte(p)); n != len(p) || err != nil {
+			t.Fatalf("got %d, %v, want %d, nil", n, err, len(p))
+		}
+	}
+}
@@ -0,0 +1,236 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"context"
+	"maps"
+	"sync"
+	"sync/atomic"
+)
+
+// contextKey is a context key of the log fields.
+type contextKey struct{}
+
+// ContextExtractor is a function which returns log fields from the context,
+// f.e. trace ID stored in the context by a tracing library. It returns nil if
+// the context has no values it is looking for.
+type ContextExtractor func(ctx context.Context) Fields
+
+// extractors is a list of registered context extractors.
+var extractors registry[ContextExtractor]
+
+// registry is a list of registered functions, f.e. context extractors. The
+// list is never changed, it is replaced on register, so it may be read
+// without locks.
+type registry[T any] struct {
+	list atomic.Pointer[[]T]
+	mu   sync.Mutex
+}
+
+// register adds the function to the registry.
+func (r *registry[T]) register(f T) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var list []T
+	if old := r.list.Load(); old != nil {
+		list = append(list, *old...)
+	}
+	list = append(list, f)
+	r.list.Store(&list)
+}
+
+// load returns the registered functions.
+func (r *registry[T]) load() []T {
+	if list := r.list.Load(); list != nil {
+		return *list
+	}
+	return nil
+}
+
+// NewContext returns a copy of the parent context with the fields added. The
+// context fields are added to the log entries made with the Context log
+// functions, f.e. InfoContext. Fields added with nested NewContext calls
+// override parent context fields with the same keys.
+//
+// The fields map is copied, so it may be changed after NewContext returns.
+func NewContext(ctx context.Context, fields Fields) context.Context {
+	if len(fields) == 0 {
+		return ctx
+	}
+	b := &boundFields{contextFields(ctx), maps.Clone(fields)}
+	return context.WithValue(ctx, contextKey{}, b)
+}
+
+// FromContext returns the fields added to the context with NewContext. It
+// returns nil if there are no fields in the context. The returned map may be
+// changed by the caller.
+func FromContext(ctx context.Context) Fields {
+	list := contextFields(ctx).list(nil)
+	if len(list) == 0 {
+		return nil
+	}
+	fields := Fields{}
+	for _, f := range list {
+		maps.Copy(fields, f)
+	}
+	return fields
+}
+
+// RegisterContextExtractor registers the function which returns log fields
+// from the context. The registered extractors are called for every log entry
+// made with the Context log functions, f.e. InfoContext, and their fields are
+// added to the log entry fields. The fields added with NewContext override the
+// extractors fields.
+func RegisterContextExtractor(extractor ContextExtractor) {
+	extractors.register(extractor)
+}
+
+// contextFields returns the fields added to the context with NewContext or
+// nil if there are no fields in the context.
+func contextFields(ctx context.Context) *boundFields {
+	b, _ := ctx.Value(contextKey{}).(*boundFields)
+	return b
+}
+
+// PrintLevelContext creates log entry at the given log level with the context
+// fields and sends it to the loggers.
+func (l *Logger) PrintLevelContext(ctx context.Context, level LogLevel, v ...any) {
+	l.printLevelContext(ctx, 1, level, v...)
+}
+
+// PrintLevelfContext creates log entry at the given log level using format
+// string with the context fields and sends it to the loggers.
+func (l *Logger) PrintLevelfContext(ctx context.Context, level LogLevel, format string, v ...any) {
+	l.printLevelfContext(ctx, 1, level, format, v...)
+}
+
+// TraceContext sends log entry with the context fields at the trace log level.
+func (l *Logger) TraceContext(ctx context.Context, v ...any) {
+	l.printLevelContext(ctx, 1, LevelTrace, v...)
+}
+
+// TracefContext sends formatted log entry with the context fields at the
+// trace log level.
+func (l *Logger) TracefContext(ctx context.Context, format string, v ...any) {
+	l.printLevelfContext(ctx, 1, LevelTrace, format, v...)
+}
+
+// DebugContext sends log entry with the context fields at the debug log level.
+func (l *Logger) DebugContext(ctx context.Context, v ...any) {
+	l.printLevelContext(ctx, 1, LevelDebug, v...)
+}
+
+// DebugfContext sends formatted log entry with the context fields at the
+// debug log level.
+func (l *Logger) DebugfContext(ctx context.Context, format string, v ...any) {
+	l.printLevelfContext(ctx, 1, LevelDebug, format, v...)
+}
+
+// InfoContext sends log entry with the context fields at the info log level.
+func (l *Logger) InfoContext(ctx context.Context, v ...any) {
+	l.printLevelContext(ctx, 1, LevelInfo, v...)
+}
+
+// InfofContext sends formatted log entry with the context fields at the info
+// log level.
+func (l *Logger) InfofContext(ctx context.Context, format string, v ...any) {
+	l.printLevelfContext(ctx, 1, LevelInfo, format, v...)
+}
+
+// WarnContext sends log entry with the context fields at the warn log level.
+func (l *Logger) WarnContext(ctx context.Context, v ...any) {
+	l.printLevelContext(ctx, 1, LevelWarn, v...)
+}
+
+// WarnfContext sends formatted log entry with the context fields at the warn
+// log level.
+func (l *Logger) WarnfContext(ctx context.Context, format string, v ...any) {
+	l.printLevelfContext(ctx, 1, LevelWarn, format, v...)
+}
+
+// ErrorContext sends log entry with the context fields at the error log level.
+func (l *Logger) ErrorContext(ctx context.Context, v ...any) {
+	l.printLevelContext(ctx, 1, LevelError, v...)
+}
+
+// ErrorfContext sends formatted log entry with the context fields at the
+// error log level.
+func (l *Logger) ErrorfContext(ctx context.Context, format string, v ...any) {
+	l.printLevelfContext(ctx, 1, LevelError, format, v...)
+}
+
+// PrintLevelContext creates log entry at the given log level with the context
+// fields and sends it to the default Logger.
+func PrintLevelContext(ctx context.Context, level LogLevel, v ...any) {
+	Default().printLevelContext(ctx, 1, level, v...)
+}
+
+// PrintLevelfContext creates log entry at the given log level using format
+// string with the context fields and sends it to the default Logger.
+func PrintLevelfContext(ctx context.Context, level LogLevel, format string, v ...any) {
+	Default().printLevelfContext(ctx, 1, level, format, v...)
+}
+
+// TraceContext sends log entry with the context fields at the trace log level
+// to the default Logger.
+func TraceContext(ctx context.Context, v ...any) {
+	Default().printLevelContext(ctx, 1, LevelTrace, v...)
+}
+
+// TracefContext sends formatted log entry with the context fields at the
+// trace log level to the default Logger.
+func TracefContext(ctx context.Context, format string, v ...any) {
+	Default().printLevelfContext(ctx, 1, LevelTrace, format, v...)
+}
+
+// DebugContext sends log entry with the context fields at the debug log level
+// to the default Logger.
+func DebugContext(ctx context.Context, v ...any) {
+	Default().printLevelContext(ctx, 1, LevelDebug, v...)
+}
+
+// DebugfContext sends formatted log entry with the context fields at the
+// debug log level to the default Logger.
+func DebugfContext(ctx context.Context, format string, v ...any) {
+	Default().printLevelfContext(ctx, 1, LevelDebug, format, v...)
+}
+
+// InfoContext sends log entry with the context fields at the info log level
+// to the default Logger.
+func InfoContext(ctx context.Context, v ...any) {
+	Default().printLevelContext(ctx, 1, LevelInfo, v...)
+}
+
+// InfofContext sends formatted log entry with the context fields at the info
+// log level to the default Logger.
+func InfofContext(ctx context.Context, format string, v ...any) {
+	Default().printLevelfContext(ctx, 1, LevelInfo, format, v...)
+}
+
+// WarnContext sends log entry with the context fields at the warn log level
+// to the default Logger.
+func WarnContext(ctx context.Context, v ...any) {
+	Default().printLevelContext(ctx, 1, LevelWarn, v...)
+}
+
+// WarnfContext sends formatted log entry with the context fields at the warn
+// log level to the default Logger.
+func WarnfContext(ctx context.Context, format string, v ...any) {
+	Default().printLevelfContext(ctx, 1, LevelWarn, format, v...)
+}
+
+// ErrorContext sends log entry with the context fields at the error log level
+// to the default Logger.
+func ErrorContext(ctx context.Context, v ...any) {
+	Default().printLevelContext(ctx, 1, LevelError, v...)
+}
+
+// ErrorfContext sends formatted log entry with the context fields at the
+// error log level to the default Logger.
+func ErrorfContext(ctx context.Context, format string, v ...any) {
+	Default().printLevelfContext(ctx, 1, LevelError, format, v...)
+}
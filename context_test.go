@@ -0,0 +1,60 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"context"
+	"testing"
+)
+
+// tenantKey is a context key used in the context extractor test.
+type tenantKey struct{}
+
+func TestContext(t *testing.T) {
+
+	RegisterContextExtractor(func(ctx context.Context) Fields {
+		if tenant, ok := ctx.Value(tenantKey{}).(string); ok {
+			return Fields{"tenant": tenant, "request_id": "from extractor"}
+		}
+		return nil
+	})
+
+	sink := &memSink{}
+	l := New(Config{DoesNotShowInitMessage: true, AddCaller: true,
+		Sinks: []Sink{sink},
+	})
+
+	// Create context with fields and extractor value
+	ctx := context.WithValue(context.Background(), tenantKey{}, "acme")
+	ctx = NewContext(ctx, Fields{"request_id": "1", "user": "alice"})
+	ctx = NewContext(ctx, Fields{"user": "bob"})
+
+	if f := FromContext(ctx); f["request_id"] != "1" || f["user"] != "bob" {
+		t.Fatalf("got wrong context fields: %v", f)
+	}
+
+	l.With(Fields{"app": "test", "user": "bound"}).
+		InfofContext(ctx, "info %d", 1, Fields{"key": "value"})
+	l.DebugContext(context.Background(), "no fields")
+	l.Close()
+
+	want := Fields{"app": "test", "tenant": "acme", "request_id": "1",
+		"user": "bob", "key": "value"}
+	fields := sink.entries[0].Fields
+	if len(fields) != len(want) {
+		t.Fatalf("got fields %v, want %v", fields, want)
+	}
+	for k, v := range want {
+		if fields[k] != v {
+			t.Fatalf("got fields %v, want %v", fields, want)
+		}
+	}
+	if sink.entries[0].Caller.Function != "github.com/kirill-scherba/log.TestContext" {
+		t.Fatalf("got wrong caller: %v", sink.entries[0].Caller)
+	}
+	if sink.entries[1].Fields != nil {
+		t.Fatalf("got fields %v, want nil", sink.entries[1].Fields)
+	}
+}
@@ -0,0 +1,147 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// field is a flattened log entry field with dotted key.
+type field struct {
+	key   string
+	value any
+}
+
+// flattenFields returns the fields sorted by key. Nested maps with string
+// keys are flattened to dotted keys, f.e. {"http": {"status": 200}} becomes
+// "http.status".
+func flattenFields(fields Fields) []field {
+	list := appendFlatten(nil, "", fields)
+	slices.SortStableFunc(list, func(a, b field) int {
+		return strings.Compare(a.key, b.key)
+	})
+	return list
+}
+
+// appendFlatten appends the map fields with the prefix to the list.
+func appendFlatten(list []field, prefix string, m map[string]any) []field {
+	for key, value := range m {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if nested, ok := nestedMap(value); ok && len(nested) > 0 {
+			list = appendFlatten(list, key, nested)
+			continue
+		}
+		list = append(list, field{key, value})
+	}
+	return list
+}
+
+// nestedMap returns the value as map[string]any if it is a map with string
+// keys.
+func nestedMap(value any) (map[string]any, bool) {
+	switch m := value.(type) {
+	case map[string]any:
+		return m, true
+	case Fields:
+		return m, true
+	case nil:
+		return nil, false
+	}
+
+	// Other maps with string keys, f.e. map[string]string
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Map || rv.Type().Key().Kind() != reflect.String {
+		return nil, false
+	}
+	m := make(map[string]any, rv.Len())
+	for iter := rv.MapRange(); iter.Next(); {
+		m[iter.Key().String()] = iter.Value().Interface()
+	}
+	return m, true
+}
+
+// appendFields writes the fields to b in "key=value" format separated by
+// spaces. The keys are sorted, nested maps are flattened to dotted keys and
+// the values are quoted if needed, so the result is always one line.
+func appendFields(b *strings.Builder, fields Fields) {
+	for i, f := range flattenFields(fields) {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(logfmtValue(f.key))
+		b.WriteByte('=')
+		b.WriteString(logfmtValue(textValue(f.value)))
+	}
+}
+
+// textValue returns the text representation of the field value:
+//
+//   - time.Time is formatted as RFC3339 with nanoseconds;
+//   - time.Duration is formatted as "1.5s";
+//   - error and fmt.Stringer return their Error and String results;
+//   - []byte is returned as a string if it is valid UTF-8 or as a base64
+//     string otherwise;
+//   - pointers are dereferenced, nil values are returned as "<nil>";
+//   - slices, arrays and structs are JSON encoded.
+func textValue(value any) string {
+	switch v := value.(type) {
+	case nil:
+		return "<nil>"
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case time.Duration:
+		return v.String()
+	case []byte:
+		if utf8.Valid(v) {
+			return string(v)
+		}
+		return base64.StdEncoding.EncodeToString(v)
+	}
+
+	// Nil pointers and interfaces may not be used as error or fmt.Stringer
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Map, reflect.Slice,
+		reflect.Func, reflect.Chan:
+		if rv.IsNil() {
+			return "<nil>"
+		}
+	}
+
+	switch v := value.(type) {
+	case error:
+		return v.Error()
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	switch rv.Kind() {
+	case reflect.Pointer:
+		return textValue(rv.Elem().Interface())
+	case reflect.Slice, reflect.Array, reflect.Struct, reflect.Map:
+		if data, err := json.Marshal(value); err == nil {
+			return string(data)
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// lineEscaper replaces new lines with escape sequences.
+var lineEscaper = strings.NewReplacer("\r", `\r`, "\n", `\n`)
+
+// escapeLine replaces new lines in s with "\n" escape sequences, so s may be
+// written as a part of one line.
+func escapeLine(s string) string { return lineEscaper.Replace(s) }
@@ -0,0 +1,67 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"errors"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAppendFields(t *testing.T) {
+
+	var nilErr *net.OpError
+	n := 42
+	fields := Fields{
+		"b":        "with space",
+		"a":        1,
+		"http":     map[string]any{"status": 200, "req": Fields{"method": "GET"}},
+		"labels":   map[string]string{"env": "prod"},
+		"time":     time.Date(2025, 10, 19, 10, 28, 50, 0, time.UTC),
+		"duration": 1500 * time.Millisecond,
+		"err":      errors.New("failed"),
+		"nil_err":  nilErr,
+		"bytes":    []byte("text"),
+		"binary":   []byte{0xff, 0x00},
+		"ip":       net.IPv4(127, 0, 0, 1),
+		"ptr":      &n,
+		"list":     []int{1, 2},
+		"multi":    "line1\nline2",
+		"quote":    `say "hi"`,
+		"empty":    "",
+	}
+
+	var b strings.Builder
+	appendFields(&b, fields)
+	want := `a=1 b="with space" binary="/wA=" bytes=text duration=1.5s empty="" ` +
+		`err=failed http.req.method=GET http.status=200 ip=127.0.0.1 ` +
+		`labels.env=prod list=[1,2] multi="line1\nline2" nil_err=<nil> ptr=42 ` +
+		`quote="say \"hi\"" time=2025-10-19T10:28:50Z`
+	if b.String() != want {
+		t.Fatalf("got fields:\n%s\nwant:\n%s", b.String(), want)
+	}
+}
+
+func TestStringOneLine(t *testing.T) {
+
+	entry := &LogEntry{
+		Timestamp: "2025-10-19T10:28:50.567024+03:00",
+		Level:     LevelError,
+		Message:   "first\nsecond\n",
+		Fields:    Fields{"key": "value"},
+		Error:     newEntryError(errors.Join(errors.New("one"), errors.New("two"))),
+		Stack:     "main.main()\n\tmain.go:12\n",
+	}
+
+	s := entry.String()
+	if strings.Contains(s, "\n") {
+		t.Fatalf("got multiline entry: %s", s)
+	}
+	if !strings.Contains(s, `[ERROR] first\nsecond, fields: key=value, error: one\ntwo`) {
+		t.Fatalf("got wrong entry: %s", s)
+	}
+}
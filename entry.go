@@ -5,8 +5,10 @@
 package log
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -15,19 +17,34 @@ import (
 //
 // It contains the following fields:
 //
+//   - ID: the unique log entry ID in ULID format, it is used as the
+//     Elasticsearch document ID, so replayed entries are not duplicated.
 //   - Timestamp: the timestamp for the log entry.
 //   - Level: the log level for the log entry.
 //   - Message: the log message for the log entry.
 //   - Fields: a map of additional fields to be included in the log
 //     entry.
+//   - Caller: the source code location of the log call, it is set when
+//     Config.AddCaller is true.
+//   - TraceID, SpanID: the W3C trace context from the context passed to the
+//     Context log functions, f.e. InfoContext.
+//   - Error: the first error passed to the log call.
+//   - Stack: the goroutine stack trace, it is set for the ERROR and above
+//     log levels when Config.StackTrace is true.
 //
 // The String method returns a JSON representation of the log entry.
 type LogEntry struct {
+	ID        string         `json:"id,omitempty"`
 	AppType   string         `json:"app_type"` // Prod, Dev, Test or some other
 	Timestamp string         `json:"@timestamp"`
 	Level     LogLevel       `json:"level"`
 	Message   string         `json:"message"`
 	Fields    map[string]any `json:"fields,omitempty"`
+	Caller    *Caller        `json:"caller,omitempty"`
+	TraceID   string         `json:"trace.id,omitempty"`
+	SpanID    string         `json:"span.id,omitempty"`
+	Error     *EntryError    `json:"error,omitempty"`
+	Stack     string         `json:"stack_trace,omitempty"`
 }
 
 // LogLevel represents a log level.
@@ -37,18 +54,22 @@ type LogLevel string
 //
 // It formats the log entry as a string in the following format:
 //
-//	<timestamp> [<level>] <message>[, fields: <fields>]
+//	<timestamp> [<level>] [<file>:<line> ]<message>[, fields: <fields>][, error: <error>][, stack_trace: <stack>]
 //
 // The timestamp is formatted as per RFC3339. The level is the log level.
 // The message is the log message. The fields are the additional fields that
-// were passed in when creating the log entry.
+// were passed in when creating the log entry, they are written in sorted
+// "key=value" format with nested maps flattened to dotted keys. New lines in
+// the message and error are escaped and the stack trace is quoted, so the
+// log entry is always one line.
 func (entry *LogEntry) String() string {
 	// Format the log entry as a string.
 
 	// If the fields are not empty, format them as a string.
-	var fields string
-	if entry.Fields != nil {
-		fields = fmt.Sprintf(", fields: %v", entry.Fields)
+	var b strings.Builder
+	if len(entry.Fields) > 0 {
+		b.WriteString(", fields: ")
+		appendFields(&b, entry.Fields)
 	}
 
 	// If the level is not none, format it as a string.
@@ -57,10 +78,27 @@ func (entry *LogEntry) String() string {
 		level = "[" + string(entry.Level) + "] "
 	}
 
+	// If the error is set, format it as a string.
+	if entry.Error != nil {
+		b.WriteString(", error: " + escapeLine(entry.Error.String()))
+	}
+
+	// If the stack trace is set, add it quoted.
+	if entry.Stack != "" {
+		b.WriteString(", stack_trace: " + strconv.Quote(entry.Stack))
+	}
+
+	// If the caller is set, format it as a string.
+	var caller string
+	if entry.Caller != nil {
+		caller = entry.Caller.String() + " "
+	}
+
 	// Return the formatted log entry as a string.
 	return fmt.Sprintf(
-		`%-36s %s%s%s`,
-		entry.Timestamp, level, strings.Trim(entry.Message, "\n"), fields,
+		`%-36s %s%s%s%s`,
+		entry.Timestamp, level, caller,
+		escapeLine(strings.Trim(entry.Message, "\n")), b.String(),
 	)
 }
 
@@ -92,22 +130,20 @@ func (entry *LogEntry) Json() string {
 // The fields parameter is a variable argument list of maps, allowing
 // the caller to pass in any number of fields to be included in the log
 // entry. The first map in the list is used as the fields for the log
-// entry.
-func entry(level LogLevel, v ...any) *LogEntry {
+// entry. The first error in the list is added to the log entry Error.
+func (l *Logger) entry(level LogLevel, v ...any) *LogEntry {
+	return l.entryContext(nil, level, v...)
+}
+
+// entryContext returns a log entry like entry does and adds the context
+// fields to it. The ctx may be nil.
+func (l *Logger) entryContext(ctx context.Context, level LogLevel, v ...any) *LogEntry {
 
 	// Get fields map[string]any from last element of v and remove it from v
 	v, fields := getFields(v)
 
 	// Make message string from v
-	message := fmt.Sprint(v...)
-
-	return &LogEntry{
-		AppType:   appType,
-		Timestamp: time.Now().Format(time.RFC3339Nano),
-		Message:   message,
-		Level:     LogLevel(level),
-		Fields:    fields,
-	}
+	return l.newEntry(ctx, level, fmt.Sprint(v...), fields, findError(v))
 }
 
 // entryf returns a log entry with the given level, format string, and values.
@@ -119,13 +155,46 @@ func entry(level LogLevel, v ...any) *LogEntry {
 //
 // The fields parameter is a variable argument list of maps, allowing the caller to pass
 // in any number of fields to be included in the log entry. The first map in the list is
-// used as the fields for the log entry.
-func entryf(level LogLevel, format string, v ...any) *LogEntry {
+// used as the fields for the log entry. The first error in the list is added to the log
+// entry Error.
+func (l *Logger) entryf(level LogLevel, format string, v ...any) *LogEntry {
+	return l.entryfContext(nil, level, format, v...)
+}
+
+// entryfContext returns a log entry like entryf does and adds the context
+// fields to it. The ctx may be nil.
+func (l *Logger) entryfContext(ctx context.Context, level LogLevel, format string, v ...any) *LogEntry {
 	// Get fields map[string]any from last element of v and remove it from v
 	v, fields := getFields(v)
 
 	// Return a log entry with the given level, message, and fields
-	return entry(level, fmt.Sprintf(format, v...), fields)
+	return l.newEntry(ctx, level, fmt.Sprintf(format, v...), fields, findError(v))
+}
+
+// newEntry returns a log entry with the given level, message, fields and
+// error. The fields bound to the Logger and the context fields are added to
+// the log entry fields and the trace context is got from the context. The ctx
+// may be nil.
+func (l *Logger) newEntry(ctx context.Context, level LogLevel, message string, fields Fields, err error) *LogEntry {
+	now := time.Now()
+	entry := &LogEntry{
+		ID:        newID(now),
+		AppType:   l.appType,
+		Timestamp: now.Format(time.RFC3339Nano),
+		Message:   message,
+		Level:     LogLevel(level),
+		Fields:    l.mergeFields(ctx, fields),
+		Error:     newEntryError(err),
+	}
+
+	// Add trace context
+	if ctx != nil {
+		if sc, ok := TraceFromContext(ctx); ok {
+			entry.TraceID, entry.SpanID = sc.TraceID, sc.SpanID
+		}
+	}
+
+	return entry
 }
 
 // getFields takes a variable argument list of values and returns a slice of the
@@ -0,0 +1,82 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// EntryError is an error passed to the log call.
+type EntryError struct {
+	// Message is the error message
+	Message string `json:"message"`
+
+	// Type is the error Go type, f.e. "*fs.PathError"
+	Type string `json:"type"`
+
+	// Chain is a list of wrapped errors got with errors.Unwrap. Errors
+	// joined with errors.Join are added depth-first.
+	Chain []*EntryError `json:"chain,omitempty"`
+}
+
+// String returns the error message and type, and the wrapped errors types,
+// f.e. "open x: no such file (*fs.PathError <- syscall.Errno)".
+func (e *EntryError) String() string {
+	types := []string{e.Type}
+	for _, c := range e.Chain {
+		types = append(types, c.Type)
+	}
+	return fmt.Sprintf("%s (%s)", e.Message, strings.Join(types, " <- "))
+}
+
+// newEntryError returns the EntryError for the error or nil if err is nil.
+func newEntryError(err error) *EntryError {
+	if err == nil {
+		return nil
+	}
+	e := &EntryError{Message: err.Error(), Type: fmt.Sprintf("%T", err)}
+	for _, wrapped := range unwrapAll(err) {
+		e.Chain = append(e.Chain, &EntryError{
+			Message: wrapped.Error(),
+			Type:    fmt.Sprintf("%T", wrapped),
+		})
+	}
+	return e
+}
+
+// unwrapAll returns all errors wrapped by err depth-first. It supports both
+// Unwrap() error and Unwrap() []error methods.
+func unwrapAll(err error) (chain []error) {
+	var wrapped []error
+	switch u := err.(type) {
+	case interface{ Unwrap() []error }:
+		wrapped = u.Unwrap()
+	default:
+		if w := errors.Unwrap(err); w != nil {
+			wrapped = []error{w}
+		}
+	}
+	for _, w := range wrapped {
+		if w == nil {
+			continue
+		}
+		chain = append(chain, w)
+		chain = append(chain, unwrapAll(w)...)
+	}
+	return
+}
+
+// findError returns the first error in the list of values or nil if there is
+// no errors.
+func findError(v []any) error {
+	for _, value := range v {
+		if err, ok := value.(error); ok {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestEntryError(t *testing.T) {
+
+	sink := &memSink{}
+	l := New(Config{DoesNotShowInitMessage: true, StackTrace: true,
+		Sinks: []Sink{sink},
+	})
+
+	// Log wrapped and joined errors
+	_, err := os.Open("/not/exists")
+	err = fmt.Errorf("read config: %w", errors.Join(err, errors.New("second")))
+	l.Error("can't start:", err, Fields{"key": "value"})
+	l.Warnf("warning: %v", err)
+	l.Close()
+
+	// Check error fields
+	e := sink.entries[0].Error
+	if e == nil || e.Type != "*fmt.wrapError" || e.Message != err.Error() {
+		t.Fatalf("got wrong error: %+v", e)
+	}
+	var types []string
+	for _, c := range e.Chain {
+		types = append(types, c.Type)
+	}
+	want := "*errors.joinError *fs.PathError syscall.Errno *errors.errorString"
+	if strings.Join(types, " ") != want {
+		t.Fatalf("got error chain %v, want %s", types, want)
+	}
+
+	// Stack trace is added to the ERROR entries only
+	if !strings.Contains(sink.entries[0].Stack, "TestEntryError") {
+		t.Fatalf("got wrong stack trace: %q", sink.entries[0].Stack)
+	}
+	if sink.entries[1].Error == nil || sink.entries[1].Stack != "" {
+		t.Fatalf("got wrong warn entry: %v", sink.entries[1])
+	}
+	if s := sink.entries[0].Json(); !strings.Contains(s, `"stack_trace":`) ||
+		!strings.Contains(s, `"type":"*fs.PathError"`) {
+		t.Fatalf("got wrong json: %s", s)
+	}
+}
+
+func TestBridgeStackTrace(t *testing.T) {
+
+	sink := &memSink{}
+	l := New(Config{DoesNotShowInitMessage: true, StackTrace: true,
+		AddCaller: true, Sinks: []Sink{sink},
+	})
+
+	// Log ERROR entries with the standard log and slog bridges
+	log.New(l.Writer(), "", 0).Println("[ERROR] std log error")
+	slog.New(NewSlogHandler(l)).Error("slog error")
+	slog.New(NewSlogHandler(l)).Info("slog info")
+	l.Close()
+
+	// Stack trace starts from the bridge caller
+	for _, entry := range sink.entries[:2] {
+		if !strings.HasPrefix(entry.Stack, "github.com/kirill-scherba/log.TestBridgeStackTrace") {
+			t.Fatalf("got wrong %q stack trace: %q", entry.Message, entry.Stack)
+		}
+		if entry.Caller == nil || entry.Caller.Function != "github.com/kirill-scherba/log.TestBridgeStackTrace" {
+			t.Fatalf("got wrong %q caller: %+v", entry.Message, entry.Caller)
+		}
+	}
+	if sink.entries[2].Stack != "" {
+		t.Fatalf("got stack trace of INFO entry: %q", sink.entries[2].Stack)
+	}
+}
@@ -4,20 +4,25 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
 	"path/filepath"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 )
 
 // EsConfig is a struct that holds information about how to send log entries to
 // Elasticsearch.
 //
-// Elasticsearch index creation in Kibana Dev Tools:
+// Elasticsearch index creation in Kibana Dev Tools (or set Bootstrap to
+// create the ILM policy, the index template with this mapping and the data
+// stream automatically):
 //
 // Delete existing index:
 /*
@@ -32,6 +37,9 @@ PUT /app-name-index
       "@timestamp": {
         "type": "date_nanos"
       },
+      "id": {
+        "type": "keyword"
+      },
       "app_type": {
         "type": "keyword"
       },
@@ -43,6 +51,34 @@ PUT /app-name-index
       },
       "fields": {
         "type": "object"
+      },
+      "trace": {
+        "properties": {
+          "id": { "type": "keyword" }
+        }
+      },
+      "span": {
+        "properties": {
+          "id": { "type": "keyword" }
+        }
+      },
+      "error": {
+        "properties": {
+          "message": { "type": "text" },
+          "type": { "type": "keyword" },
+          "chain": { "type": "object", "enabled": false }
+        }
+      },
+      "stack_trace": {
+        "type": "text",
+        "index": false
+      },
+      "caller": {
+        "properties": {
+          "file": { "type": "keyword" },
+          "line": { "type": "integer" },
+          "function": { "type": "keyword" }
+        }
       }
     }
   }
@@ -51,7 +87,13 @@ PUT /app-name-index
 type EsConfig struct {
 	ES_URL        string // Elasticsearch URL
 	ES_API_KEY    string // Elasticsearch API key
-	ES_INDEX_NAME string // Elasticsearch index name
+	ES_INDEX_NAME string // Elasticsearch index name or index name template
+
+	// The ES_INDEX_NAME may be a template expanded for every log entry, f.e.
+	// "logs-{app}-{env}-{2006.01.02}" makes daily indexes. The placeholders
+	// are {app} (AppShort), {env} (AppType), {level}, {fields.key} (the log
+	// entry field value) and Go time layouts applied to the log entry
+	// timestamp in UTC.
 
 	// Time to hold before sending log entries to Elasticsearch.
 	// If not set, Default is 5 seconds.
@@ -68,41 +110,259 @@ type EsConfig struct {
 	// Maximum number of failover files to keep on disk.
 	// If not set, Default is 10.
 	MaxFailoverFiles int
+
+	// Minimum log level sent to Elasticsearch.
+	// If not set, all log levels are sent.
+	MinLevel LogLevel
+
+	// Schema of the documents sent to Elasticsearch. If set to EsSchemaECS,
+	// the documents are Elastic Common Schema compliant and the service
+	// name, environment and dataset are filled from Config.AppShort and
+	// Config.AppType. ECS fields are mapped by the ecs@mappings component
+	// template of Elasticsearch 8.
+	// If not set, Default is EsSchemaDefault, the mapping is shown above.
+	Schema EsSchema
+
+	// File to write documents permanently rejected by Elasticsearch, f.e.
+	// on mapping conflicts, one JSON record with the rejection reason per
+	// line.
+	// If not set, Default is "/tmp/APP_SHORT_NAME/deadletter.jsonl".
+	DeadLetterFile string
+
+	// Interval to check and send failover files from disk.
+	// If not set, Default is 10 seconds.
+	FailoverReplayInterval time.Duration
+
+	// Initial delay before the next failover replay after failed send, it is
+	// doubled after every consecutive failure with random jitter.
+	// If not set, Default is 1 second.
+	RetryBackoff time.Duration
+
+	// Maximum delay before the next failover replay after failed send.
+	// If not set, Default is 1 minute.
+	MaxRetryBackoff time.Duration
+
+	// Number of consecutive send failures after which the circuit breaker
+	// opens. While it is open the log entries are saved to disk without
+	// sending and one probe request is sent every BreakerProbeInterval.
+	// If not set, Default is 5.
+	BreakerThreshold int
+
+	// Interval of probe requests while the circuit breaker is open.
+	// If not set, Default is 30 seconds.
+	BreakerProbeInterval time.Duration
+
+	// Queue overflow configuration. If not set, log calls block while the
+	// Elasticsearch sink queue is full.
+	Overflow OverflowConfig
+
+	// Directory of the write-ahead spool. If set, every log entry is written
+	// to the spool segment file before it is added to the sink queue, and
+	// the segment is deleted after all its log entries are sent or saved to
+	// the failover directory. The segments left after crash are sent on the
+	// next start, which gives at-least-once delivery across restarts.
+	// If not set, the spool is not used.
+	SpoolDir string
+
+	// Maximum size of the spool segment file.
+	// If not set, Default is 16 MiB.
+	SpoolSegmentSize int64
+
+	// SpoolSync syncs the spool segment file to disk after every log entry.
+	// It survives the operating system crash but slows down the log calls.
+	SpoolSync bool
+
+	// Basic authorization user name and password, used if ES_API_KEY and
+	// BearerToken are not set.
+	Username string
+	Password string
+
+	// Bearer token authorization, used if ES_API_KEY is not set.
+	BearerToken string
+
+	// Headers are custom HTTP headers added to all Elasticsearch requests.
+	Headers map[string]string
+
+	// CAFile is a PEM file and CACert is a PEM data of the CA certificates
+	// added to the system pool to verify Elasticsearch server certificate.
+	CAFile string
+	CACert []byte
+
+	// Client certificate and key PEM files.
+	CertFile string
+	KeyFile  string
+
+	// InsecureSkipVerify disables the Elasticsearch server certificate
+	// verification. Use it with development clusters only.
+	InsecureSkipVerify bool
+
+	// Proxy URL, f.e. "http://proxy:3128".
+	// If not set, the proxy from the environment variables is used.
+	Proxy string
+
+	// Transport is a custom HTTP transport. If set, the TLS and Proxy
+	// options are not used.
+	Transport http.RoundTripper
+
+	// HTTPClient is a custom HTTP client. If set, the TLS, Proxy and
+	// Transport options are not used.
+	HTTPClient *http.Client
+
+	// Pipeline is the ingest pipeline name used in bulk requests, f.e.
+	// "ent-search-generic-ingestion".
+	// If not set, the pipeline is not used.
+	Pipeline string
+
+	// IngestPipeline is the ingest pipeline installed with the Pipeline name
+	// before the first bulk request. Existing pipeline is replaced.
+	// If nil, the pipeline is not installed.
+	IngestPipeline *EsPipeline
+
+	// Refresh is the bulk requests refresh policy: "true", "false" or
+	// "wait_for".
+	// If not set, the Elasticsearch default is used.
+	Refresh string
+
+	// Routing is the bulk requests shard routing value.
+	// If not set, the Elasticsearch default is used.
+	Routing string
+
+	// RequestTimeout is the Elasticsearch requests timeout, it is not used
+	// with HTTPClient.
+	// If not set, Default is 10 seconds.
+	RequestTimeout time.Duration
+
+	// Bootstrap creates the ILM policy, the index template with the mapping
+	// shown above and the data stream named ES_INDEX_NAME before the first
+	// bulk request, if they do not exist.
+	// If nil, nothing is created.
+	Bootstrap *EsBootstrap
+
+	// Nodes are the Elasticsearch node URLs used together with ES_URL, f.e.
+	// []string{"https://es1:9200", "https://es2:9200"}. The requests are
+	// sent to the nodes round-robin. A node which does not answer or answers
+	// with 502, 503 or 504 status is marked dead, the request is sent to the
+	// next node and the dead node is not used until it is resurrected.
+	Nodes []string
+
+	// Time to keep the failed node dead before it is used again, it is
+	// doubled after every consecutive failure of the node up to 32 times.
+	// If not set, Default is 30 seconds.
+	DeadNodeTimeout time.Duration
+
+	// Interval to check the dead nodes with GET / request, the node which
+	// answers is resurrected before its dead node timeout expires.
+	// If not set, Default is 10 seconds, negative value disables checks.
+	HealthCheckInterval time.Duration
+
+	// Interval to discover the cluster nodes with GET /_nodes/http request.
+	// The discovered nodes publish addresses replace the configured nodes,
+	// the first configured node URL scheme is used for them.
+	// If not set, the nodes are not discovered.
+	SniffInterval time.Duration
 }
 
-// es is a struct that holds information about how to send log entries to
-// Elasticsearch.
+// es is a Sink that sends log entries to Elasticsearch.
 type es struct {
 
-	// esEntryChannel is a channel that receives log entries for sending to es
-	esEntryChannel chan *LogEntry
-
 	// Elasticsearch log parameters
 	*EsConfig
+
+	// Logger which owns this Elasticsearch logger
+	l *Logger
+
+	// entries is a slice to hold log entries before sending
+	entries []*LogEntry
+
+	// ecs is the ECS metadata, it is nil if Schema is not EsSchemaECS
+	ecs *ecsMeta
+
+	// breaker is the Elasticsearch requests circuit breaker
+	breaker *breaker
+
+	// index is the parsed ES_INDEX_NAME template
+	index *indexTemplate
+
+	// client is the Elasticsearch HTTP client
+	client *http.Client
+
+	// bulkPath is the bulk API path with query parameters
+	bulkPath string
+
+	// pipelineDone and bootstrapDone are true when the IngestPipeline and
+	// the Bootstrap are done
+	pipelineDone  bool
+	bootstrapDone bool
+	bootstrapMu   sync.Mutex
+
+	// spool is the write-ahead spool, it is nil if SpoolDir is not set
+	spool *spool
+
+	// nodes is the Elasticsearch nodes pool
+	nodes *nodePool
+
+	// stop stops the failover replay and the nodes watch goroutines, wg
+	// waits them
+	stop chan struct{}
+	wg   sync.WaitGroup
 }
 
-// init sets up the Elasticsearch logger and starts the entry handler goroutine.
+// newEs creates the Elasticsearch sink.
 //
-// The entry handler goroutine aggregates log entries in a slice until either
-// the slice reaches the maximum size (l.entriesToHold) or the time to hold
-// (l.timeToHold) expires.
+// The sink aggregates log entries in a slice until either the slice reaches
+// the maximum size (EntriesToHold) or the time to hold (TimeToHold) expires.
 // When either condition is met, it sends the aggregated log entries to
 // Elasticsearch using the sendToElasticsearch method.
-// If the Elasticsearch config is not set, the function does nothing.
 // It also sets the default values for the Elasticsearch config if they are not
 // set.
-func (e *es) init(appShort string, esConfig *EsConfig) {
-	if esConfig == nil {
-		return
+func newEs(l *Logger, esConfig *EsConfig) *es {
+	e := &es{EsConfig: esConfig, l: l}
+
+	// Set default request timeout
+	if e.EsConfig.RequestTimeout == 0 {
+		e.EsConfig.RequestTimeout = 10 * time.Second
 	}
 
-	// Set elasticsearch logger config
-	e.EsConfig = esConfig
+	// Create HTTP client, use the default client if options are not valid
+	var err error
+	if e.client, err = e.newClient(); err != nil {
+		l.stdout.Println("error creating Elasticsearch HTTP client, default used:", err)
+		e.client = &http.Client{Timeout: e.RequestTimeout}
+	}
+
+	// Create nodes pool
+	if e.EsConfig.DeadNodeTimeout == 0 {
+		e.EsConfig.DeadNodeTimeout = 30 * time.Second
+	}
+	if e.EsConfig.HealthCheckInterval == 0 {
+		e.EsConfig.HealthCheckInterval = 10 * time.Second
+	}
+	e.nodes = newNodePool(append([]string{e.ES_URL}, e.Nodes...), e.DeadNodeTimeout)
+
+	// Check ingest pipeline name
+	if e.EsConfig.IngestPipeline != nil && e.Pipeline == "" {
+		l.stdout.Println("Elasticsearch ingest pipeline name is not set, pipeline is not installed")
+		e.EsConfig.IngestPipeline = nil
+	}
+
+	// Make bulk API path
+	query := url.Values{}
+	for key, value := range map[string]string{
+		"pipeline": e.Pipeline, "refresh": e.Refresh, "routing": e.Routing,
+	} {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	e.bulkPath = "/_bulk"
+	if len(query) > 0 {
+		e.bulkPath += "?" + query.Encode()
+	}
 
 	// Set failover directory
 	if e.EsConfig.FailoverDir == "" {
 		tempDir := os.TempDir()
-		e.EsConfig.FailoverDir = tempDir + "/" + appShort + "/failover"
+		e.EsConfig.FailoverDir = tempDir + "/" + l.appShort + "/failover"
 	}
 	os.MkdirAll(e.EsConfig.FailoverDir, 0755)
 
@@ -121,93 +381,170 @@ func (e *es) init(appShort string, esConfig *EsConfig) {
 		e.EsConfig.MaxFailoverFiles = 10
 	}
 
-	// Create entry channel
-	e.esEntryChannel = make(chan *LogEntry, esConfig.EntriesToHold)
+	// Set default dead-letter file
+	if e.EsConfig.DeadLetterFile == "" {
+		e.EsConfig.DeadLetterFile = filepath.Join(os.TempDir(), l.appShort, "deadletter.jsonl")
+	}
+
+	// Gather ECS metadata once
+	if e.EsConfig.Schema == EsSchemaECS {
+		e.ecs = newEcsMeta(l)
+	}
+
+	// Set default retry parameters
+	if e.EsConfig.FailoverReplayInterval == 0 {
+		e.EsConfig.FailoverReplayInterval = 10 * time.Second
+	}
+	if e.EsConfig.RetryBackoff == 0 {
+		e.EsConfig.RetryBackoff = time.Second
+	}
+	if e.EsConfig.MaxRetryBackoff == 0 {
+		e.EsConfig.MaxRetryBackoff = time.Minute
+	}
+	if e.EsConfig.BreakerThreshold == 0 {
+		e.EsConfig.BreakerThreshold = 5
+	}
+	if e.EsConfig.BreakerProbeInterval == 0 {
+		e.EsConfig.BreakerProbeInterval = 30 * time.Second
+	}
+	e.breaker = &breaker{
+		threshold:     e.EsConfig.BreakerThreshold,
+		probeInterval: e.EsConfig.BreakerProbeInterval,
+	}
+
+	// Parse index name template, use it as is if it is not valid
+	e.index, err = parseIndexTemplate(e.ES_INDEX_NAME, l.appShort, l.appType)
+	if err != nil {
+		l.stdout.Println("error parsing Elasticsearch index name, used as is:", err)
+		e.index = &indexTemplate{
+			parts:  []indexPart{{literal: e.ES_INDEX_NAME}},
+			static: true,
+		}
+	}
+
+	// Set bootstrap defaults, the index name template must start with a
+	// literal prefix or {app} and {env} placeholders, otherwise the index
+	// template would match other indexes
+	if e.EsConfig.Bootstrap != nil {
+		name := e.index.bootstrapName()
+		if name == "" {
+			l.stdout.Printf("error bootstrapping Elasticsearch: index name %q "+
+				"has no literal prefix, bootstrap skipped", e.ES_INDEX_NAME)
+			e.EsConfig.Bootstrap = nil
+		} else {
+			e.EsConfig.Bootstrap.setDefaults(name)
+		}
+	}
+
+	// Create write-ahead spool
+	if e.EsConfig.SpoolDir != "" {
+		e.spool, err = newSpool(e.SpoolDir, e.SpoolSegmentSize, e.SpoolSync)
+		if err != nil {
+			e.spool = nil
+			l.stdout.Println("error creating Elasticsearch spool, spool disabled:", err)
+		}
+	}
+
+	// Start failover replay and nodes watch
+	e.stop = make(chan struct{})
+	e.wg.Add(2)
+	go e.replayFailover(e.stop, &e.wg)
+	go e.watchNodes(e.stop, &e.wg)
 
-	// Start entry handler
-	loggers.wgStart.Add(1)
-	go e.entryHandler()
+	return e
 }
 
-// close closes the entry channel and stop the entry processing goroutine.
-func (e *es) close() {
-	close(e.esEntryChannel)
+// Name returns the Elasticsearch sink name.
+func (e *es) Name() string { return "elasticsearch" }
+
+// MinLevel returns the Elasticsearch sink minimum log level.
+func (e *es) MinLevel() LogLevel { return e.EsConfig.MinLevel }
+
+// Overflow returns the Elasticsearch sink queue overflow configuration.
+func (e *es) Overflow() OverflowConfig { return e.EsConfig.Overflow }
+
+// QueueSize returns the Elasticsearch sink queue size.
+func (e *es) QueueSize() int { return e.EntriesToHold }
+
+// FlushInterval returns the time to hold log entries before sending them to
+// Elasticsearch.
+func (e *es) FlushInterval() time.Duration { return e.TimeToHold }
+
+// Write appends log entry to the slice of log entries. If the slice has
+// reached the maximum size, the log entries are sent to Elasticsearch.
+// The failover batches from disk are sent by the replay goroutine.
+func (e *es) Write(entry *LogEntry) error {
+
+	// Append the new log entry to the slice
+	e.entries = append(e.entries, entry)
+
+	// If the slice has reached the maximum size, send the log entries
+	if len(e.entries) >= e.EntriesToHold {
+		e.sendOrSave(e.entries)
+		e.entries = nil
+	}
+
+	return nil
 }
 
-// entryHandler is a goroutine that consumes log entries from the entryChannel.
-// It aggregates log entries in a slice until either the slice reaches the maximum
-// size (l.entriesToHold) or the time to hold (l.timeToHold) expires.
-// When either condition is met, it sends the aggregated log entries to Elasticsearch
-// using the sendToElasticsearch method.
-// If sending fails, it buffers the batch for later retries.
-func (e *es) entryHandler() {
-	loggers.wgStart.Done()
+// Flush sends held log entries to Elasticsearch. If sending fails, it saves
+// the batch to disk for later retries.
+func (e *es) Flush() error {
 
-	loggers.wgClose.Add(1)
-	defer loggers.wgClose.Done()
+	// If there are any log entries in the slice, send them to Elasticsearch
+	if len(e.entries) > 0 {
+		e.sendOrSave(e.entries)
+		e.entries = nil
+	}
 
-	// Slice to hold log entries
-	var entries []*LogEntry
-	ticker := time.NewTicker(e.TimeToHold)
-	defer ticker.Stop()
-
-	// Loop until the goroutine is stopped
-	for {
-		// First, try to send any buffered batches
-		if e.processFailoverFiles() {
-			// If we successfully sent a file, continue the loop to immediately try the next one.
-			continue
-		}
+	return nil
+}
 
-		select {
-
-		// Case for when a new log entry is received
-		case entry, ok := <-e.esEntryChannel:
-			if !ok {
-				// Before exiting, try to send any remaining entries
-				if len(entries) > 0 {
-					e.sendOrSave(entries)
-				}
-				// If the channel is closed, exit the goroutine
-				return
-			}
-			// Append the new log entry to the slice
-			entries = append(entries, entry)
-
-			// If the slice has reached the maximum size, send the log entries
-			if len(entries) >= e.EntriesToHold {
-				e.sendOrSave(entries)
-				entries = nil
-				ticker.Reset(e.TimeToHold) // Reset ticker after sending a full batch
-			}
+// Close stops the failover replay and the nodes watch, sends remaining log
+// entries to Elasticsearch and closes the spool.
+func (e *es) Close() error {
+	close(e.stop)
+	e.wg.Wait()
+	if len(e.entries) > 0 {
+		e.sendOrSave(e.entries)
+		e.entries = nil
+	}
+	e.spool.close()
+	return nil
+}
 
-		// Case for when the time to hold expires
-		case <-ticker.C:
-			// If there are any log entries in the slice, send them to Elasticsearch
-			if len(entries) > 0 {
-				e.sendOrSave(entries)
-				entries = nil
-			}
-		}
+// retryFailoverFiles sends failover files from disk until sending fails or
+// there are no more files.
+func (e *es) retryFailoverFiles() {
+	for e.processFailoverFiles() {
+		// If we successfully sent a file, try the next one immediately.
 	}
 }
 
 // sendOrSave attempts to send a batch of entries, and if it fails, saves it
-// to a failover file on disk.
-func (e *es) sendOrSave(entries []*LogEntry) {
-	err := e.sendToElasticsearch(entries...)
+// to a failover file on disk. If the batch is sent partially, only the
+// entries failed with retryable statuses are saved. It returns false if the
+// batch was not saved, the batch entries are left in the spool in this case.
+func (e *es) sendOrSave(batch []*LogEntry) bool {
+	err := e.send(batch)
 	if err != nil {
-		stdoutLogger.Println(
+		e.l.stdout.Println(
 			"error sending log entries to Elasticsearch, saving to disk for retry:",
 			err)
 
-		// On failure, save the batch to a disk file.
-		if err := e.saveBatchToDisk(entries); err == nil {
-			stdoutLogger.Println("successfully saved failed batch to disk")
-		} else {
-			stdoutLogger.Println("CRITICAL: Failed to save batch to disk:", err)
+		// Get entries to retry and save them to a disk file.
+		if entries := e.failed(batch, err); len(entries) > 0 {
+			if err := e.saveBatchToDisk(entries); err != nil {
+				e.l.stdout.Println("CRITICAL: Failed to save batch to disk:", err)
+				return false
+			}
+			e.l.stdout.Println("successfully saved failed batch to disk")
 		}
 	}
+
+	// Acknowledge spooled entries
+	e.spool.ack(batch)
+	return true
 }
 
 // saveBatchToDisk saves a slice of LogEntry to a unique file in the failover directory.
@@ -258,31 +595,66 @@ func (e *es) processFailoverFiles() bool {
 	// Read the file
 	data, err := os.ReadFile(filePath)
 	if err != nil {
-		stdoutLogger.Printf("error reading failover file %s: %v", filePath, err)
+		e.l.stdout.Printf("error reading failover file %s: %v", filePath, err)
 		return false
 	}
 
 	// Unmarshal the data
 	var entries []*LogEntry
 	if err := json.Unmarshal(data, &entries); err != nil {
-		stdoutLogger.Printf("error unmarshalling failover file %s: %v, deleting corrupt file.", filePath, err)
+		e.l.stdout.Printf("error unmarshalling failover file %s: %v, deleting corrupt file.", filePath, err)
 		os.Remove(filePath)
 		return false
 	}
 
-	// Attempt to send the batch
-	stdoutLogger.Printf("attempting to send batch from failover file: %s", filePath)
-	if err := e.sendToElasticsearch(entries...); err == nil {
-		stdoutLogger.Printf("successfully sent batch from %s, deleting file.", filePath)
+	// Attempt to send the batch, skip it silently if the breaker is open
+	err = e.send(entries)
+	if errors.Is(err, ErrBreakerOpen) {
+		return false
+	}
+	if err == nil {
+		e.l.stdout.Printf("successfully sent batch from %s, deleting file.", filePath)
 		os.Remove(filePath)
 		return true
 	}
 
+	// If the batch is sent partially, keep only entries to retry in the file
+	if retry := e.failed(entries, err); len(retry) < len(entries) {
+		if len(retry) == 0 {
+			e.l.stdout.Printf("batch from %s sent partially: %v, deleting file.", filePath, err)
+			os.Remove(filePath)
+			return true
+		}
+		if data, err := json.Marshal(retry); err == nil {
+			os.WriteFile(filePath, data, 0644)
+		}
+	}
+
 	// If sending fails, retry later
-	stdoutLogger.Printf("failed to send batch from %s, will retry later: %v", filePath, err)
+	e.l.stdout.Printf("failed to send batch from %s, will retry later: %v", filePath, err)
 	return false
 }
 
+// hasFailoverFiles returns true if there are failover files on disk.
+func (e *es) hasFailoverFiles() bool {
+	files, _ := filepath.Glob(filepath.Join(e.FailoverDir, "*.json"))
+	return len(files) > 0
+}
+
+// failed returns the entries to retry after the send error. If the error is
+// BulkError, the rejected entries are written to the dead-letter file and
+// only the entries failed with retryable statuses are returned.
+func (e *es) failed(entries []*LogEntry, err error) []*LogEntry {
+	var bulkErr *BulkError
+	if !errors.As(err, &bulkErr) {
+		return entries
+	}
+	if err := e.saveDeadLetters(bulkErr.rejected); err != nil {
+		e.l.stdout.Println("CRITICAL: Failed to save rejected documents:", err)
+	}
+	return bulkErr.retry
+}
+
 // sendToElasticsearch Sends entrys to Elasticsearch
 func (e *es) sendToElasticsearch(entrys ...*LogEntry) (err error) {
 
@@ -292,12 +664,20 @@ func (e *es) sendToElasticsearch(entrys ...*LogEntry) (err error) {
 		return
 	}
 
-	// Create string buffer and reader
+	// Create string buffer and reader, the entries are grouped by index and
+	// reordered in the bulk request order
 	var buf strings.Builder
-	for _, entry := range entrys {
-		json := string(entry.Json())
-		buf.WriteString(fmt.Sprintf(`{ "index": { "_index": "%s" } }`+"\n", e.ES_INDEX_NAME))
-		buf.WriteString(json + "\n")
+	var ordered []*LogEntry
+	for _, group := range e.index.groupByIndex(entrys) {
+		for _, entry := range group.entries {
+			doc, err := e.document(entry)
+			if err != nil {
+				return err
+			}
+			buf.WriteString(bulkAction(group.index, entry.ID) + "\n")
+			buf.WriteString(doc + "\n")
+			ordered = append(ordered, entry)
+		}
 	}
 
 	// Create a gzip writer
@@ -312,19 +692,9 @@ func (e *es) sendToElasticsearch(entrys ...*LogEntry) (err error) {
 		return fmt.Errorf("Error closing gzip writer: %v", err)
 	}
 
-	// Create HTTP request
-	req, err := http.NewRequest("POST", e.ES_URL+"/_bulk?pretty&pipeline=ent-search-generic-ingestion", &gzipBuf)
-	if err != nil {
-		err = fmt.Errorf("Error creating HTTP request: %v", err)
-		return
-	}
-	req.Header.Set("Authorization", "ApiKey "+e.ES_API_KEY)
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Content-Encoding", "gzip")
-
-	// Execute HTTP request with 10 second timeout
-	client := &http.Client{Timeout: 10 * time.Second}
-	resp, err := client.Do(req)
+	// Execute HTTP request on the next alive node
+	header := http.Header{"Content-Encoding": {"gzip"}}
+	resp, err := e.do("POST", e.bulkPath, gzipBuf.Bytes(), header)
 	if err != nil {
 		err = fmt.Errorf("Error sending HTTP request: %v", err)
 		return
@@ -350,5 +720,48 @@ func (e *es) sendToElasticsearch(entrys ...*LogEntry) (err error) {
 		return err
 	}
 
-	return
+	// Check bulk items errors
+	return checkBulkResponse(resp.Body, ordered)
+}
+
+// newRequest creates HTTP request to the Elasticsearch node API path with the
+// JSON content type, authorization and custom headers. The body may be nil.
+func (e *es) newRequest(node, method, path string, body []byte) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, node+path, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	e.setAuth(req)
+	return req, nil
+}
+
+// bulkAction returns the bulk API create action of the document. The create
+// action with the log entry ID fails with 409 conflict status if the document
+// was already indexed, so the replayed log entries are not duplicated. The
+// document ID is generated by Elasticsearch if id is empty.
+func bulkAction(index, id string) string {
+	action := map[string]string{"_index": index}
+	if id != "" {
+		action["_id"] = id
+	}
+	data, _ := json.Marshal(map[string]any{"create": action})
+	return string(data)
+}
+
+// document returns the Elasticsearch document of the log entry in the
+// configured schema.
+func (e *es) document(entry *LogEntry) (string, error) {
+	if e.ecs == nil {
+		return entry.Json(), nil
+	}
+	data, err := e.ecs.ecsJson(entry)
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal ECS document: %w", err)
+	}
+	return string(data), nil
 }
@@ -0,0 +1,258 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+)
+
+// EsBootstrap is a configuration of the Elasticsearch destination created on
+// startup: the ILM policy, the composable index template and the data stream
+// named ES_INDEX_NAME. The index template matches ES_INDEX_NAME exactly.
+//
+// If ES_INDEX_NAME is a template, the {app} and {env} placeholders are
+// expanded and other placeholders are replaced with "*" in the index
+// template pattern, f.e. "logs-{app}-{env}-{level}" makes "logs-myapp-prod-*"
+// pattern, and the data streams are created by Elasticsearch on the first
+// bulk requests. The pattern must not start with "*", otherwise nothing is
+// created. If ES_INDEX_NAME has time placeholders, f.e.
+// "logs-{app}-{2006.01.02}", every index name is used for a limited time, so
+// the indexes are created as regular indexes without data streams and
+// rollover, and the ILM policy only deletes them.
+//
+// Existing policy, template and data stream are not changed.
+type EsBootstrap struct {
+	// Index template name.
+	// If not set, Default is ES_INDEX_NAME or, if it is a template, the
+	// pattern before the first "*" with the application name added if the
+	// template has no {app} placeholder, f.e. "logs-myapp-prod".
+	TemplateName string
+
+	// ILM policy name.
+	// If not set, Default is the template name + "-policy".
+	PolicyName string
+
+	// Maximum age of the data stream backing index before rollover, it is
+	// not used with time placeholders in ES_INDEX_NAME.
+	// If not set, Default is "1d".
+	RolloverMaxAge string
+
+	// Maximum primary shard size of the data stream backing index before
+	// rollover.
+	// If not set, Default is "50gb".
+	RolloverMaxSize string
+
+	// Age of the backing index after rollover, or of the index created for
+	// ES_INDEX_NAME with time placeholders, when it is deleted.
+	// If not set, Default is "30d".
+	DeleteAfter string
+}
+
+// esMapping is the mapping of the EsSchemaDefault documents, it is the same
+// as shown in the EsConfig description.
+const esMapping = `{
+  "properties": {
+    "@timestamp": { "type": "date_nanos" },
+    "id": { "type": "keyword" },
+    "app_type": { "type": "keyword" },
+    "level": { "type": "keyword" },
+    "message": { "type": "text" },
+    "fields": { "type": "object" },
+    "trace": { "properties": { "id": { "type": "keyword" } } },
+    "span": { "properties": { "id": { "type": "keyword" } } },
+    "error": {
+      "properties": {
+        "message": { "type": "text" },
+        "type": { "type": "keyword" },
+        "chain": { "type": "object", "enabled": false }
+      }
+    },
+    "stack_trace": { "type": "text", "index": false },
+    "caller": {
+      "properties": {
+        "file": { "type": "keyword" },
+        "line": { "type": "integer" },
+        "function": { "type": "keyword" }
+      }
+    }
+  }
+}`
+
+// setDefaults sets the default values of the bootstrap configuration.
+func (b *EsBootstrap) setDefaults(index string) {
+	if b.TemplateName == "" {
+		b.TemplateName = index
+	}
+	if b.PolicyName == "" {
+		b.PolicyName = index + "-policy"
+	}
+	if b.RolloverMaxAge == "" {
+		b.RolloverMaxAge = "1d"
+	}
+	if b.RolloverMaxSize == "" {
+		b.RolloverMaxSize = "50gb"
+	}
+	if b.DeleteAfter == "" {
+		b.DeleteAfter = "30d"
+	}
+}
+
+// bootstrap installs the IngestPipeline and creates the ILM policy, the
+// index template and the data stream if they do not exist. It does nothing
+// if the IngestPipeline and the Bootstrap are not configured or they were
+// already done. The pipeline and the Bootstrap are done independently: the
+// failed step is repeated before the next bulk request if Elasticsearch is
+// not available, other errors are printed and the step is not repeated.
+func (e *es) bootstrap() error {
+	if e.Bootstrap == nil && e.IngestPipeline == nil {
+		return nil
+	}
+
+	e.bootstrapMu.Lock()
+	defer e.bootstrapMu.Unlock()
+
+	var pipelineErr, bootstrapErr error
+	if e.IngestPipeline != nil && !e.pipelineDone {
+		e.pipelineDone, pipelineErr = e.bootstrapStep(
+			"installing Elasticsearch ingest pipeline", e.installPipeline)
+	}
+	if e.Bootstrap != nil && !e.bootstrapDone {
+		e.bootstrapDone, bootstrapErr = e.bootstrapStep(
+			"bootstrapping Elasticsearch", e.createBootstrap)
+	}
+	return errors.Join(pipelineErr, bootstrapErr)
+}
+
+// bootstrapStep runs the bootstrap step and returns true if it must not be
+// repeated: it is done or Elasticsearch answered with client error, which is
+// printed.
+func (e *es) bootstrapStep(name string, step func() error) (done bool, err error) {
+	err = step()
+	if err == nil {
+		return true, nil
+	}
+
+	// Do not repeat if Elasticsearch answered with client error
+	if se, ok := err.(*esStatusError); ok && se.status < 500 &&
+		se.status != http.StatusTooManyRequests {
+		e.l.stdout.Printf("error %s, skipped: %v", name, err)
+		return true, nil
+	}
+	return false, fmt.Errorf("error %s: %w", name, err)
+}
+
+// createBootstrap creates missing ILM policy, index template and data stream.
+func (e *es) createBootstrap() (err error) {
+	b := e.Bootstrap
+	index := e.ES_INDEX_NAME
+
+	// ILM policy, the indexes of the index name with time placeholders are
+	// not rolled over
+	dated := e.index.has(indexTime)
+	phases := map[string]any{
+		"delete": map[string]any{
+			"min_age": b.DeleteAfter,
+			"actions": map[string]any{"delete": map[string]any{}},
+		},
+	}
+	if !dated {
+		phases["hot"] = map[string]any{"actions": map[string]any{"rollover": map[string]any{
+			"max_age":                b.RolloverMaxAge,
+			"max_primary_shard_size": b.RolloverMaxSize,
+		}}}
+	}
+	policy := map[string]any{"policy": map[string]any{"phases": phases}}
+	if err = e.createIfMissing("/_ilm/policy/"+url.PathEscape(b.PolicyName), policy); err != nil {
+		return
+	}
+
+	// Composable index template with data stream, the ECS documents are
+	// mapped by the built-in ecs@mappings component template. The fixed index
+	// name is matched exactly and the index name template pattern has the
+	// expanded {app} and {env}, so other indexes are not changed
+	template := map[string]any{
+		"settings": map[string]any{"index.lifecycle.name": b.PolicyName},
+	}
+	body := map[string]any{
+		"index_patterns": []string{e.index.pattern()},
+		"priority":       200,
+		"template":       template,
+	}
+	if !dated {
+		body["data_stream"] = map[string]any{}
+	}
+	if e.Schema == EsSchemaECS {
+		body["composed_of"] = []string{"ecs@mappings"}
+	} else {
+		template["mappings"] = json.RawMessage(esMapping)
+	}
+	if err = e.createIfMissing("/_index_template/"+url.PathEscape(b.TemplateName), body); err != nil {
+		return
+	}
+
+	// Data stream, the data streams of the index name template are created
+	// by Elasticsearch on the first bulk request
+	if !e.index.static {
+		return nil
+	}
+	return e.createIfMissing("/_data_stream/"+url.PathEscape(index), nil)
+}
+
+// createIfMissing checks the Elasticsearch API path with GET request and
+// creates it with PUT request and the JSON body if it is not found.
+func (e *es) createIfMissing(path string, body any) error {
+	status, _, err := e.doJSON("GET", path, nil)
+	switch {
+	case err != nil:
+		return err
+	case status == http.StatusOK:
+		return nil
+	case status != http.StatusNotFound:
+		return &esStatusError{"GET " + path, status}
+	}
+
+	status, data, err := e.doJSON("PUT", path, body)
+	switch {
+	case err != nil:
+		return err
+	case status != http.StatusOK:
+		return &esStatusError{fmt.Sprintf("PUT %s: %s", path, data), status}
+	}
+	e.l.stdout.Println("created Elasticsearch", path)
+	return nil
+}
+
+// doJSON sends HTTP request with the JSON body to the Elasticsearch API path
+// and returns the response status and body. The body may be nil.
+func (e *es) doJSON(method, path string, body any) (status int, data []byte, err error) {
+	if body != nil {
+		if data, err = json.Marshal(body); err != nil {
+			return
+		}
+	}
+	resp, err := e.do(method, path, data, nil)
+	if err != nil {
+		return
+	}
+	defer resp.Body.Close()
+	data, err = io.ReadAll(resp.Body)
+	return resp.StatusCode, data, err
+}
+
+// esStatusError is an error of the Elasticsearch API request which returned
+// unexpected HTTP status.
+type esStatusError struct {
+	request string
+	status  int
+}
+
+func (e *esStatusError) Error() string {
+	return fmt.Sprintf("%s: unexpected status %d", e.request, e.status)
+}
@@ -0,0 +1,141 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// BulkError is an error returned when some documents of the Elasticsearch
+// bulk request were not indexed. Documents which already exist (409 item
+// status) are counted as indexed. The retryable documents (429 and 5xx item
+// statuses) are saved to the failover directory and sent again later, the
+// rejected documents are written to the dead-letter file.
+type BulkError struct {
+	// Total is a number of documents in the bulk request
+	Total int
+
+	// Retryable is a number of documents failed with retryable status
+	Retryable int
+
+	// Rejected is a number of permanently rejected documents
+	Rejected int
+
+	// retry is a list of log entries to retry
+	retry []*LogEntry
+
+	// rejected is a list of rejected log entries with reasons
+	rejected []deadLetter
+}
+
+// Error returns the bulk error message with documents counts.
+func (e *BulkError) Error() string {
+	return fmt.Sprintf(
+		"bulk request: %d of %d documents failed, %d retryable, %d rejected",
+		e.Retryable+e.Rejected, e.Total, e.Retryable, e.Rejected)
+}
+
+// bulkResponse is the Elasticsearch bulk API response.
+type bulkResponse struct {
+	Errors bool                      `json:"errors"`
+	Items  []map[string]bulkItemResp `json:"items"`
+}
+
+// bulkItemResp is the result of one bulk API action.
+type bulkItemResp struct {
+	Status int             `json:"status"`
+	Error  json.RawMessage `json:"error,omitempty"`
+}
+
+// deadLetter is a record of the dead-letter file.
+type deadLetter struct {
+	Time   string          `json:"@timestamp"`
+	Status int             `json:"status"`
+	Reason json.RawMessage `json:"reason,omitempty"`
+	Entry  *LogEntry       `json:"entry"`
+}
+
+// retryableStatus returns true if the bulk item with this status may be sent
+// again: too many requests or server errors.
+func retryableStatus(status int) bool {
+	return status == http.StatusTooManyRequests || status >= 500
+}
+
+// checkBulkResponse decodes the bulk API response and returns BulkError if
+// some of entries were not indexed.
+func checkBulkResponse(body io.Reader, entries []*LogEntry) error {
+
+	// Decode response
+	var resp bulkResponse
+	if err := json.NewDecoder(body).Decode(&resp); err != nil {
+		return fmt.Errorf("error decoding bulk response: %w", err)
+	}
+	if !resp.Errors {
+		return nil
+	}
+	if len(resp.Items) != len(entries) {
+		return fmt.Errorf("bulk response has %d items, want %d",
+			len(resp.Items), len(entries))
+	}
+
+	// Check items statuses, every item has one action key
+	bulkErr := &BulkError{Total: len(entries)}
+	for i, item := range resp.Items {
+		for _, result := range item {
+			switch {
+			case result.Status >= 200 && result.Status < 300,
+				result.Status == http.StatusConflict:
+			case retryableStatus(result.Status):
+				bulkErr.Retryable++
+				bulkErr.retry = append(bulkErr.retry, entries[i])
+			default:
+				bulkErr.Rejected++
+				bulkErr.rejected = append(bulkErr.rejected, deadLetter{
+					Time:   time.Now().Format(time.RFC3339Nano),
+					Status: result.Status,
+					Reason: result.Error,
+					Entry:  entries[i],
+				})
+			}
+		}
+	}
+	if bulkErr.Retryable+bulkErr.Rejected == 0 {
+		return nil
+	}
+
+	return bulkErr
+}
+
+// saveDeadLetters appends rejected documents with their reasons to the
+// dead-letter file, one JSON record per line.
+func (e *es) saveDeadLetters(letters []deadLetter) error {
+	if len(letters) == 0 {
+		return nil
+	}
+
+	// Open dead-letter file
+	os.MkdirAll(filepath.Dir(e.DeadLetterFile), 0755)
+	f, err := os.OpenFile(e.DeadLetterFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening dead-letter file: %w", err)
+	}
+	defer f.Close()
+
+	// Write records
+	enc := json.NewEncoder(f)
+	for _, letter := range letters {
+		if err := enc.Encode(letter); err != nil {
+			return fmt.Errorf("error writing dead-letter file: %w", err)
+		}
+	}
+
+	return nil
+}
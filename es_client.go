@@ -0,0 +1,104 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+)
+
+// newClient returns the Elasticsearch HTTP client. It returns the
+// HTTPClient if it is set, otherwise it creates the client with the
+// Transport or with the new transport configured with the TLS and proxy
+// options.
+func (e *es) newClient() (*http.Client, error) {
+	if e.HTTPClient != nil {
+		return e.HTTPClient, nil
+	}
+
+	// Use custom transport
+	client := &http.Client{Timeout: e.RequestTimeout}
+	if e.Transport != nil {
+		client.Transport = e.Transport
+		return client, nil
+	}
+
+	// Create transport
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	tlsConfig, err := e.tlsConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport.TLSClientConfig = tlsConfig
+	if e.Proxy != "" {
+		proxyURL, err := url.Parse(e.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL: %w", err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	client.Transport = transport
+
+	return client, nil
+}
+
+// tlsConfig returns the TLS configuration with the custom CA certificates,
+// the client certificate and the InsecureSkipVerify option.
+func (e *es) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{InsecureSkipVerify: e.InsecureSkipVerify}
+
+	// Add CA certificates to the system pool
+	caCert := e.CACert
+	if e.CAFile != "" {
+		data, err := os.ReadFile(e.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("error reading CA file: %w", err)
+		}
+		caCert = append(append([]byte(nil), caCert...), data...)
+	}
+	if len(caCert) > 0 {
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, errors.New("no CA certificates found")
+		}
+		config.RootCAs = pool
+	}
+
+	// Load client certificate
+	if e.CertFile != "" || e.KeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(e.CertFile, e.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("error loading client certificate: %w", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+
+	return config, nil
+}
+
+// setAuth sets the authorization and custom headers of the Elasticsearch
+// request. Only one of the API key, bearer token or basic authorizations is
+// used, in this order.
+func (e *es) setAuth(req *http.Request) {
+	switch {
+	case e.ES_API_KEY != "":
+		req.Header.Set("Authorization", "ApiKey "+e.ES_API_KEY)
+	case e.BearerToken != "":
+		req.Header.Set("Authorization", "Bearer "+e.BearerToken)
+	case e.Username != "":
+		req.SetBasicAuth(e.Username, e.Password)
+	}
+	for key, value := range e.Headers {
+		req.Header.Set(key, value)
+	}
+}
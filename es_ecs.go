@@ -0,0 +1,150 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// EsSchema is a schema of the documents sent to Elasticsearch.
+type EsSchema string
+
+// Elasticsearch document schemas
+const (
+	// EsSchemaDefault is the LogEntry JSON representation: app_type, level,
+	// message, fields and so on.
+	EsSchemaDefault EsSchema = ""
+
+	// EsSchemaECS is the Elastic Common Schema: log.level, service.name,
+	// service.environment, event.dataset, host.name, process.pid and so on.
+	EsSchemaECS EsSchema = "ecs"
+)
+
+// ecsVersion is the Elastic Common Schema version of the ECS documents.
+const ecsVersion = "8.11.0"
+
+// ecsDocument is the Elastic Common Schema document.
+type ecsDocument struct {
+	Timestamp string     `json:"@timestamp"`
+	Message   string     `json:"message"`
+	Log       ecsLog     `json:"log"`
+	Service   ecsService `json:"service"`
+	Event     ecsEvent   `json:"event"`
+	Host      ecsHost    `json:"host"`
+	Process   ecsProcess `json:"process"`
+	ECS       ecsInfo    `json:"ecs"`
+	Trace     *ecsID     `json:"trace,omitempty"`
+	Span      *ecsID     `json:"span,omitempty"`
+	Error     *ecsError  `json:"error,omitempty"`
+	Fields    Fields     `json:"fields,omitempty"`
+}
+
+type ecsLog struct {
+	Level  string     `json:"level,omitempty"`
+	Origin *ecsOrigin `json:"origin,omitempty"`
+}
+
+type ecsOrigin struct {
+	File     ecsFile `json:"file"`
+	Function string  `json:"function,omitempty"`
+}
+
+type ecsFile struct {
+	Name string `json:"name"`
+	Line int    `json:"line"`
+}
+
+type ecsService struct {
+	Name        string `json:"name,omitempty"`
+	Environment string `json:"environment,omitempty"`
+}
+
+type ecsEvent struct {
+	ID      string `json:"id,omitempty"`
+	Dataset string `json:"dataset,omitempty"`
+}
+
+type ecsHost struct {
+	Name string `json:"name,omitempty"`
+}
+
+type ecsProcess struct {
+	Pid int `json:"pid"`
+}
+
+type ecsInfo struct {
+	Version string `json:"version"`
+}
+
+type ecsID struct {
+	ID string `json:"id"`
+}
+
+type ecsError struct {
+	Message    string        `json:"message"`
+	Type       string        `json:"type"`
+	StackTrace string        `json:"stack_trace,omitempty"`
+	Chain      []*EntryError `json:"chain,omitempty"`
+}
+
+// ecsMeta is the ECS service, host and process metadata, it is gathered once
+// when the Elasticsearch sink is created.
+type ecsMeta struct {
+	service ecsService
+	event   ecsEvent
+	host    ecsHost
+	process ecsProcess
+}
+
+// newEcsMeta gathers the ECS metadata of the Logger application.
+func newEcsMeta(l *Logger) *ecsMeta {
+	hostname, _ := os.Hostname()
+	return &ecsMeta{
+		service: ecsService{Name: l.appShort, Environment: l.appType},
+		event:   ecsEvent{Dataset: l.appShort},
+		host:    ecsHost{Name: hostname},
+		process: ecsProcess{Pid: os.Getpid()},
+	}
+}
+
+// ecsJson returns the ECS document of the log entry.
+func (m *ecsMeta) ecsJson(entry *LogEntry) ([]byte, error) {
+	doc := ecsDocument{
+		Timestamp: entry.Timestamp,
+		Message:   strings.Trim(entry.Message, "\n"),
+		Log:       ecsLog{Level: strings.ToLower(string(entry.Level))},
+		Service:   m.service,
+		Event:     ecsEvent{entry.ID, m.event.Dataset},
+		Host:      m.host,
+		Process:   m.process,
+		ECS:       ecsInfo{ecsVersion},
+		Fields:    entry.Fields,
+	}
+
+	// Source code location
+	if c := entry.Caller; c != nil {
+		doc.Log.Origin = &ecsOrigin{ecsFile{c.File, c.Line}, c.Function}
+	}
+
+	// Trace context
+	if entry.TraceID != "" {
+		doc.Trace = &ecsID{entry.TraceID}
+	}
+	if entry.SpanID != "" {
+		doc.Span = &ecsID{entry.SpanID}
+	}
+
+	// Error and stack trace
+	if entry.Error != nil || entry.Stack != "" {
+		doc.Error = &ecsError{StackTrace: entry.Stack}
+		if e := entry.Error; e != nil {
+			doc.Error.Message, doc.Error.Type, doc.Error.Chain = e.Message, e.Type, e.Chain
+		}
+	}
+
+	return json.Marshal(&doc)
+}
@@ -0,0 +1,235 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// indexPart is a part of the index name template: a literal string or a
+// placeholder.
+type indexPart struct {
+	literal string
+	kind    indexPartKind
+	arg     string // time layout or field key
+}
+
+// indexPartKind is a kind of the index name template part.
+type indexPartKind int
+
+const (
+	indexLiteral indexPartKind = iota
+	indexApp
+	indexEnv
+	indexLevel
+	indexField
+	indexTime
+)
+
+// indexTemplate is a parsed index name template, f.e.
+// "logs-{app}-{env}-{2006.01.02}".
+type indexTemplate struct {
+	parts  []indexPart
+	app    string
+	env    string
+	static bool
+}
+
+// parseIndexTemplate parses the index name template. The placeholders in
+// curly braces are:
+//
+//   - {app}: the Config.AppShort;
+//   - {env}: the Config.AppType;
+//   - {level}: the log entry level;
+//   - {fields.key}: the log entry field value, nested fields are separated
+//     by dots, f.e. {fields.http.method};
+//   - any other placeholder is a Go time layout applied to the log entry
+//     timestamp in UTC, f.e. {2006.01.02} or {2006.01}.
+//
+// The placeholder values are converted to lower case and the characters
+// which are not allowed in the index names are replaced with "_".
+func parseIndexTemplate(template, app, env string) (*indexTemplate, error) {
+	t := &indexTemplate{app: app, env: env, static: true}
+	for rest := template; rest != ""; {
+
+		// Literal part
+		i := strings.IndexByte(rest, '{')
+		if i < 0 {
+			t.parts = append(t.parts, indexPart{literal: rest})
+			break
+		}
+		if i > 0 {
+			t.parts = append(t.parts, indexPart{literal: rest[:i]})
+		}
+
+		// Placeholder part
+		j := strings.IndexByte(rest[i:], '}')
+		if j < 0 {
+			return nil, fmt.Errorf("index name template %q: unclosed '{'", template)
+		}
+		name := rest[i+1 : i+j]
+		rest = rest[i+j+1:]
+		t.static = false
+
+		switch {
+		case name == "":
+			return nil, fmt.Errorf("index name template %q: empty placeholder", template)
+		case name == "app":
+			t.parts = append(t.parts, indexPart{kind: indexApp})
+		case name == "env":
+			t.parts = append(t.parts, indexPart{kind: indexEnv})
+		case name == "level":
+			t.parts = append(t.parts, indexPart{kind: indexLevel})
+		case strings.HasPrefix(name, "fields."):
+			t.parts = append(t.parts, indexPart{kind: indexField, arg: name[len("fields."):]})
+		default:
+			t.parts = append(t.parts, indexPart{kind: indexTime, arg: name})
+		}
+	}
+	return t, nil
+}
+
+// prefix returns the literal part of the template before the first
+// placeholder.
+func (t *indexTemplate) prefix() string {
+	if len(t.parts) == 0 || t.parts[0].kind != indexLiteral {
+		return ""
+	}
+	return t.parts[0].literal
+}
+
+// pattern returns the index pattern matching all index names of the template:
+// {app} and {env} are expanded and other placeholders are replaced with "*",
+// f.e. "logs-myapp-prod-*" for "logs-{app}-{env}-{2006.01.02}".
+func (t *indexTemplate) pattern() string {
+	var b strings.Builder
+	for _, part := range t.parts {
+		switch part.kind {
+		case indexLiteral:
+			b.WriteString(part.literal)
+		case indexApp:
+			b.WriteString(indexValue(t.app))
+		case indexEnv:
+			b.WriteString(indexValue(t.env))
+		default:
+			if !strings.HasSuffix(b.String(), "*") {
+				b.WriteString("*")
+			}
+		}
+	}
+	return b.String()
+}
+
+// has returns true if the template has placeholder of the kind.
+func (t *indexTemplate) has(kind indexPartKind) bool {
+	for _, part := range t.parts {
+		if part.kind == kind {
+			return true
+		}
+	}
+	return false
+}
+
+// bootstrapName returns the default name of the index template and the ILM
+// policy: the pattern before the first wildcard with the application name
+// added if the template has no {app} placeholder, f.e. "logs-myapp-prod" for
+// "logs-{app}-{env}-{2006.01.02}". It returns empty string if the pattern
+// starts with a wildcard, such template would match other indexes.
+func (t *indexTemplate) bootstrapName() string {
+	if t.static {
+		return t.prefix()
+	}
+	name, _, _ := strings.Cut(t.pattern(), "*")
+	name = strings.TrimRight(name, "-_.")
+	if name == "" {
+		return ""
+	}
+	if !t.has(indexApp) && t.app != "" {
+		name += "-" + indexValue(t.app)
+	}
+	return name
+}
+
+// expand returns the index name of the log entry.
+func (t *indexTemplate) expand(entry *LogEntry) string {
+	var b strings.Builder
+	for _, part := range t.parts {
+		switch part.kind {
+		case indexLiteral:
+			b.WriteString(part.literal)
+			continue
+		case indexApp:
+			b.WriteString(indexValue(t.app))
+		case indexEnv:
+			b.WriteString(indexValue(t.env))
+		case indexLevel:
+			b.WriteString(indexValue(string(entry.Level)))
+		case indexField:
+			b.WriteString(indexValue(fieldValue(entry.Fields, part.arg)))
+		case indexTime:
+			ts, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+			if err != nil {
+				ts = time.Now()
+			}
+			b.WriteString(indexValue(ts.UTC().Format(part.arg)))
+		}
+	}
+	return b.String()
+}
+
+// fieldValue returns the text value of the field with dotted key or empty
+// string if the field is not found.
+func fieldValue(fields Fields, key string) string {
+	for _, f := range flattenFields(fields) {
+		if f.key == key {
+			return textValue(f.value)
+		}
+	}
+	return ""
+}
+
+// indexValue returns the placeholder value allowed in the index name: it is
+// lower case and the not allowed characters are replaced with "_". Empty
+// value is replaced with "unknown".
+func indexValue(value string) string {
+	if value == "" {
+		return "unknown"
+	}
+	return strings.Map(func(r rune) rune {
+		switch r {
+		case ' ', '"', '*', '\\', '<', '|', ',', '>', '/', '?', '#', ':':
+			return '_'
+		}
+		return r
+	}, strings.ToLower(value))
+}
+
+// indexGroup is a list of log entries sent to the same index.
+type indexGroup struct {
+	index   string
+	entries []*LogEntry
+}
+
+// groupByIndex groups the log entries by their index names keeping the
+// order of the first entries of the groups and of the entries in groups.
+func (t *indexTemplate) groupByIndex(entries []*LogEntry) (groups []indexGroup) {
+	if t.static {
+		return []indexGroup{{t.prefix(), entries}}
+	}
+	byIndex := make(map[string]int)
+	for _, entry := range entries {
+		index := t.expand(entry)
+		i, ok := byIndex[index]
+		if !ok {
+			i = len(groups)
+			byIndex[index] = i
+			groups = append(groups, indexGroup{index: index})
+		}
+		groups[i].entries = append(groups[i].entries, entry)
+	}
+	return
+}
@@ -0,0 +1,299 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// esNode is an Elasticsearch node of the nodes pool.
+type esNode struct {
+	url       string
+	failures  int       // consecutive failures, 0 if the node is alive
+	deadUntil time.Time // time when the dead node is resurrected
+}
+
+// nodePool is a list of Elasticsearch nodes selected round-robin. The failed
+// nodes are marked dead and are not selected until their resurrection time,
+// which grows exponentially with consecutive failures.
+type nodePool struct {
+	mu      sync.Mutex
+	nodes   []*esNode
+	next    int
+	timeout backoff
+}
+
+// newNodePool creates the nodes pool from the node URLs. Empty and duplicate
+// URLs are skipped, but the pool always has at least one node.
+func newNodePool(urls []string, deadTimeout time.Duration) *nodePool {
+	p := &nodePool{timeout: backoff{deadTimeout, 32 * deadTimeout}}
+	p.set(urls)
+	if len(p.nodes) == 0 {
+		p.nodes = []*esNode{{}}
+	}
+	return p
+}
+
+// set replaces the pool nodes with the node URLs keeping the state of the
+// nodes which are already in the pool.
+func (p *nodePool) set(urls []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var nodes []*esNode
+	for _, u := range urls {
+		if u == "" || slices.ContainsFunc(nodes, func(n *esNode) bool { return n.url == u }) {
+			continue
+		}
+		i := slices.IndexFunc(p.nodes, func(n *esNode) bool { return n.url == u })
+		if i >= 0 {
+			nodes = append(nodes, p.nodes[i])
+			continue
+		}
+		nodes = append(nodes, &esNode{url: u})
+	}
+	if len(nodes) > 0 {
+		p.nodes, p.next = nodes, 0
+	}
+}
+
+// urls returns the pool nodes URLs.
+func (p *nodePool) urls() (urls []string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, n := range p.nodes {
+		urls = append(urls, n.url)
+	}
+	return
+}
+
+// len returns the number of nodes in the pool.
+func (p *nodePool) len() int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return len(p.nodes)
+}
+
+// pick returns the next alive node or the dead node whose resurrection time
+// has come. If all nodes are dead, it returns the node which is resurrected
+// first, so requests are never stopped by the pool.
+func (p *nodePool) pick() *esNode {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var first *esNode
+	for range len(p.nodes) {
+		n := p.nodes[p.next]
+		p.next = (p.next + 1) % len(p.nodes)
+		if n.failures == 0 || !now.Before(n.deadUntil) {
+			return n
+		}
+		if first == nil || n.deadUntil.Before(first.deadUntil) {
+			first = n
+		}
+	}
+	return first
+}
+
+// dead marks the node dead until its resurrection time. It returns true if
+// the node was alive.
+func (p *nodePool) dead(n *esNode) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	n.deadUntil = time.Now().Add(p.timeout.delay(n.failures))
+	n.failures++
+	return n.failures == 1
+}
+
+// alive marks the node alive. It returns true if the node was dead.
+func (p *nodePool) alive(n *esNode) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	wasDead := n.failures > 0
+	n.failures = 0
+	return wasDead
+}
+
+// deadNodes returns the dead nodes.
+func (p *nodePool) deadNodes() (nodes []*esNode) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for _, n := range p.nodes {
+		if n.failures > 0 {
+			nodes = append(nodes, n)
+		}
+	}
+	return
+}
+
+// unavailableStatus returns true if the HTTP status means that the node can
+// not process requests now, f.e. it is restarting behind a proxy.
+func unavailableStatus(status int) bool {
+	switch status {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// do sends HTTP request to the Elasticsearch API path on the next node of the
+// pool. If the node is not available, it is marked dead and the request is
+// sent to the next node until all nodes are tried. The body may be nil.
+func (e *es) do(method, path string, body []byte, header http.Header) (resp *http.Response, err error) {
+	attempts := e.nodes.len()
+	for i := range attempts {
+		node := e.nodes.pick()
+		resp, err = e.doNode(node.url, method, path, body, header)
+		if err == nil && !unavailableStatus(resp.StatusCode) {
+			if e.nodes.alive(node) {
+				e.l.stdout.Println("Elasticsearch node is alive:", node.url)
+			}
+			return
+		}
+
+		// Mark node dead and try the next one
+		reason := fmt.Sprint(err)
+		if err == nil {
+			reason = resp.Status
+		}
+		if e.nodes.dead(node) {
+			e.l.stdout.Printf("Elasticsearch node %s is dead: %s", node.url, reason)
+		}
+		if err == nil && i < attempts-1 {
+			resp.Body.Close()
+		}
+	}
+	return
+}
+
+// doNode sends HTTP request to the Elasticsearch API path on the node.
+func (e *es) doNode(node, method, path string, body []byte, header http.Header) (*http.Response, error) {
+	req, err := e.newRequest(node, method, path, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range header {
+		req.Header[key] = values
+	}
+	return e.client.Do(req)
+}
+
+// watchNodes is a goroutine that checks the dead nodes every
+// HealthCheckInterval and discovers the cluster nodes every SniffInterval.
+// It exits when the stop channel is closed.
+func (e *es) watchNodes(stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	var healthCheck, sniff <-chan time.Time
+	if e.HealthCheckInterval > 0 {
+		ticker := time.NewTicker(e.HealthCheckInterval)
+		defer ticker.Stop()
+		healthCheck = ticker.C
+	}
+	if e.SniffInterval > 0 {
+		e.sniff()
+		ticker := time.NewTicker(e.SniffInterval)
+		defer ticker.Stop()
+		sniff = ticker.C
+	}
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-healthCheck:
+			e.checkNodes()
+		case <-sniff:
+			e.sniff()
+		}
+	}
+}
+
+// checkNodes sends GET request to the root path of the dead nodes and marks
+// them alive if they answer.
+func (e *es) checkNodes() {
+	for _, node := range e.nodes.deadNodes() {
+		resp, err := e.doNode(node.url, "GET", "/", nil, nil)
+		if err != nil {
+			continue
+		}
+		resp.Body.Close()
+		if !unavailableStatus(resp.StatusCode) && e.nodes.alive(node) {
+			e.l.stdout.Println("Elasticsearch node is alive:", node.url)
+		}
+	}
+}
+
+// nodesHTTP is the Elasticsearch nodes info API response with the HTTP
+// section only.
+type nodesHTTP struct {
+	Nodes map[string]struct {
+		HTTP struct {
+			PublishAddress string `json:"publish_address"`
+		} `json:"http"`
+	} `json:"nodes"`
+}
+
+// sniff gets the cluster nodes with the nodes info API and replaces the pool
+// nodes with them. The nodes are not changed if the request fails.
+func (e *es) sniff() {
+	resp, err := e.do("GET", "/_nodes/http", nil, nil)
+	if err != nil {
+		e.l.stdout.Println("error sniffing Elasticsearch nodes:", err)
+		return
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		e.l.stdout.Println("error sniffing Elasticsearch nodes:",
+			&esStatusError{"GET /_nodes/http", resp.StatusCode})
+		return
+	}
+	var info nodesHTTP
+	if err := json.NewDecoder(resp.Body).Decode(&info); err != nil {
+		e.l.stdout.Println("error decoding Elasticsearch nodes:", err)
+		return
+	}
+
+	// Make node URLs with the scheme of the configured nodes
+	scheme := "http"
+	if u, err := url.Parse(e.nodes.urls()[0]); err == nil && u.Scheme != "" {
+		scheme = u.Scheme
+	}
+	var urls []string
+	for _, node := range info.Nodes {
+		if u := nodeURL(scheme, node.HTTP.PublishAddress); u != "" {
+			urls = append(urls, u)
+		}
+	}
+	slices.Sort(urls)
+	e.nodes.set(urls)
+}
+
+// nodeURL returns the node URL of the publish address. The publish address
+// is "ip:port" or "hostname/ip:port" if the node has a hostname, the
+// hostname is used in this case to verify the node TLS certificate.
+func nodeURL(scheme, address string) string {
+	host, ip, found := strings.Cut(address, "/")
+	if !found {
+		ip, host = host, ""
+	}
+	ipHost, port, err := net.SplitHostPort(ip)
+	if err != nil {
+		return ""
+	}
+	if host == "" {
+		host = ipHost
+	}
+	return scheme + "://" + net.JoinHostPort(host, port)
+}
@@ -0,0 +1,66 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// EsPipeline is an Elasticsearch ingest pipeline definition, f.e.:
+//
+//	&EsPipeline{
+//		Description: "parse user agent and client location",
+//		Processors: []EsProcessor{
+//			UserAgentProcessor("fields.user_agent", "user_agent"),
+//			GeoIPProcessor("fields.client_ip", "client.geo"),
+//		},
+//	}
+type EsPipeline struct {
+	Description string        `json:"description,omitempty"`
+	Processors  []EsProcessor `json:"processors"`
+}
+
+// EsProcessor is an ingest pipeline processor, f.e.
+// {"lowercase": {"field": "fields.method"}}.
+type EsProcessor map[string]any
+
+// UserAgentProcessor returns the processor which parses the user agent
+// string field to the target field. Documents without the field are
+// skipped.
+func UserAgentProcessor(field, targetField string) EsProcessor {
+	return EsProcessor{"user_agent": map[string]any{
+		"field":          field,
+		"target_field":   targetField,
+		"ignore_missing": true,
+	}}
+}
+
+// GeoIPProcessor returns the processor which adds the geographical location
+// of the IP address field to the target field. Documents without the field
+// are skipped.
+func GeoIPProcessor(field, targetField string) EsProcessor {
+	return EsProcessor{"geoip": map[string]any{
+		"field":          field,
+		"target_field":   targetField,
+		"ignore_missing": true,
+	}}
+}
+
+// installPipeline creates or replaces the IngestPipeline with the Pipeline
+// name.
+func (e *es) installPipeline() error {
+	path := "/_ingest/pipeline/" + url.PathEscape(e.Pipeline)
+	status, data, err := e.doJSON("PUT", path, e.IngestPipeline)
+	switch {
+	case err != nil:
+		return err
+	case status != http.StatusOK:
+		return &esStatusError{fmt.Sprintf("PUT %s: %s", path, data), status}
+	}
+	e.l.stdout.Println("installed Elasticsearch ingest pipeline", e.Pipeline)
+	return nil
+}
@@ -0,0 +1,154 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// ErrBreakerOpen is returned when log entries are not sent to Elasticsearch
+// because the circuit breaker is open after consecutive failures.
+var ErrBreakerOpen = errors.New("elasticsearch circuit breaker is open")
+
+// backoff is an exponential backoff schedule with jitter.
+type backoff struct {
+	min, max time.Duration
+}
+
+// delay returns the delay before the attempt, attempts start from 0. The
+// delay is min doubled on every attempt up to max, with random jitter in
+// range [delay/2, delay).
+func (b backoff) delay(attempt int) time.Duration {
+	d := b.min
+	for range attempt {
+		if d >= b.max/2 {
+			d = b.max
+			break
+		}
+		d *= 2
+	}
+	d = min(d, b.max)
+	if d <= 1 {
+		return d
+	}
+	return d/2 + rand.N(d/2)
+}
+
+// breaker is a circuit breaker. It opens after threshold consecutive failures
+// and then allows one probe request per probe interval until the probe
+// succeeds.
+type breaker struct {
+	threshold     int
+	probeInterval time.Duration
+
+	mu       sync.Mutex
+	failures int       // consecutive failures
+	openAt   time.Time // time when the breaker was opened or probed
+	probing  bool      // probe request is in progress
+}
+
+// allow returns true if the request may be sent.
+func (b *breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Closed
+	if b.failures < b.threshold {
+		return true
+	}
+
+	// Open, allow one probe per probe interval
+	if b.probing || time.Since(b.openAt) < b.probeInterval {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// success closes the breaker.
+func (b *breaker) success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures, b.probing = 0, false
+}
+
+// failure counts the failed request and opens the breaker when the number
+// of consecutive failures reaches the threshold. A failed probe keeps the
+// breaker open for the next probe interval.
+func (b *breaker) failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openAt, b.probing = time.Now(), false
+	}
+}
+
+// isOpen returns true if the breaker is open.
+func (b *breaker) isOpen() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.threshold
+}
+
+// send bootstraps Elasticsearch if needed and sends log entries to it through
+// the circuit breaker. It returns ErrBreakerOpen without sending if the
+// breaker is open. Partially
+// indexed batches without retryable items do not count as failures, the
+// Elasticsearch is reachable in this case.
+func (e *es) send(entries []*LogEntry) error {
+	if !e.breaker.allow() {
+		return ErrBreakerOpen
+	}
+	if err := e.bootstrap(); err != nil {
+		e.breaker.failure()
+		return err
+	}
+	err := e.sendToElasticsearch(entries...)
+	var bulkErr *BulkError
+	if err == nil || errors.As(err, &bulkErr) && bulkErr.Retryable == 0 {
+		e.breaker.success()
+	} else {
+		e.breaker.failure()
+	}
+	return err
+}
+
+// replayFailover is a goroutine that sends the spool segments left after the
+// previous run and then sends failover files to Elasticsearch on its own
+// timer. It checks the files every FailoverReplayInterval while
+// Elasticsearch accepts them and backs off exponentially with jitter after
+// failures. It exits when the stop channel is closed.
+func (e *es) replayFailover(stop chan struct{}, wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	e.replaySpool()
+
+	bo := backoff{e.RetryBackoff, e.MaxRetryBackoff}
+	timer := time.NewTimer(e.FailoverReplayInterval)
+	defer timer.Stop()
+
+	var attempt int
+	for {
+		select {
+		case <-stop:
+			return
+		case <-timer.C:
+		}
+
+		// Send failover files, back off if they are left on disk
+		e.retryFailoverFiles()
+		if e.hasFailoverFiles() {
+			timer.Reset(bo.delay(attempt))
+			attempt++
+			continue
+		}
+		attempt = 0
+		timer.Reset(e.FailoverReplayInterval)
+	}
+}
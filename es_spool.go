@@ -0,0 +1,237 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// Default spool segment size
+const defaultSpoolSegmentSize = 16 << 20
+
+// spool is a write-ahead log of the Elasticsearch sink. It is a directory of
+// append-only segment files with one JSON log entry per line. The segment is
+// deleted when all its log entries are acknowledged and the next segment is
+// started.
+type spool struct {
+	dir         string
+	segmentSize int64
+	sync        bool
+
+	mu      sync.Mutex
+	f       *os.File          // current segment
+	seq     uint64            // current segment number
+	size    int64             // current segment size
+	pending map[uint64]int    // number of not acknowledged entries by segment
+	entries map[string]uint64 // segments of not acknowledged entries by ID
+	old     []string          // segments left after the previous run
+}
+
+// newSpool creates the spool in the directory. The segments left after the
+// previous run are not changed, they are sent by the Elasticsearch sink
+// replaySpool method.
+func newSpool(dir string, segmentSize int64, sync bool) (s *spool, err error) {
+	if segmentSize <= 0 {
+		segmentSize = defaultSpoolSegmentSize
+	}
+	s = &spool{
+		dir:         dir,
+		segmentSize: segmentSize,
+		sync:        sync,
+		pending:     make(map[uint64]int),
+		entries:     make(map[string]uint64),
+	}
+
+	// Find old segments and start the next segment number
+	if err = os.MkdirAll(dir, 0755); err != nil {
+		return
+	}
+	s.old, err = filepath.Glob(filepath.Join(dir, "*.seg"))
+	if err != nil {
+		return
+	}
+	slices.Sort(s.old)
+	for _, name := range s.old {
+		var seq uint64
+		fmt.Sscanf(filepath.Base(name), "%d.seg", &seq)
+		s.seq = max(s.seq, seq+1)
+	}
+
+	err = s.open()
+	return
+}
+
+// segmentName returns the segment file name.
+func (s *spool) segmentName(seq uint64) string {
+	return filepath.Join(s.dir, fmt.Sprintf("%020d.seg", seq))
+}
+
+// open creates the current segment file.
+func (s *spool) open() (err error) {
+	s.f, err = os.OpenFile(s.segmentName(s.seq), os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	s.size = 0
+	return
+}
+
+// append writes log entry to the current segment and starts the next
+// segment when the current one is full. The log entries are tracked by ID, so
+// the copies of the entry, f.e. read from the spill file, are acknowledged
+// too. The log entries without ID are written but not tracked.
+func (s *spool) append(entry *LogEntry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.f == nil {
+		return fmt.Errorf("spool is closed")
+	}
+
+	// Write log entry
+	n, err := s.f.Write(append(data, '\n'))
+	if err != nil {
+		return err
+	}
+	if s.sync {
+		if err = s.f.Sync(); err != nil {
+			return err
+		}
+	}
+	s.size += int64(n)
+	if _, ok := s.entries[entry.ID]; entry.ID != "" && !ok {
+		s.pending[s.seq]++
+		s.entries[entry.ID] = s.seq
+	}
+
+	// Start next segment
+	if s.size >= s.segmentSize {
+		s.f.Close()
+		s.removeDone(s.seq)
+		s.seq++
+		return s.open()
+	}
+
+	return nil
+}
+
+// ack acknowledges log entries delivery. Segments which have no pending log
+// entries are deleted, except the current one. It does nothing if s is nil.
+func (s *spool) ack(entries []*LogEntry) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, entry := range entries {
+		seq, ok := s.entries[entry.ID]
+		if !ok {
+			continue
+		}
+		delete(s.entries, entry.ID)
+		s.pending[seq]--
+		if seq != s.seq {
+			s.removeDone(seq)
+		}
+	}
+}
+
+// removeDone deletes the segment if all its log entries are acknowledged.
+func (s *spool) removeDone(seq uint64) {
+	if s.pending[seq] > 0 {
+		return
+	}
+	delete(s.pending, seq)
+	os.Remove(s.segmentName(seq))
+}
+
+// close closes the current segment and deletes it if all its log entries
+// are acknowledged. It does nothing if s is nil.
+func (s *spool) close() {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.f != nil {
+		s.f.Close()
+		s.f = nil
+		s.removeDone(s.seq)
+	}
+}
+
+// readSegment reads log entries from the segment file. The corrupted lines,
+// f.e. the last line written on crash, are skipped.
+func readSegment(name string) (entries []*LogEntry, err error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		entry := new(LogEntry)
+		if json.Unmarshal(scanner.Bytes(), entry) == nil {
+			entries = append(entries, entry)
+		}
+	}
+	err = scanner.Err()
+	return
+}
+
+// Spool writes log entry to the Elasticsearch sink spool before it is added
+// to the sink queue.
+func (e *es) Spool(entry *LogEntry) error {
+	if e.spool == nil {
+		return nil
+	}
+	return e.spool.append(entry)
+}
+
+// Release acknowledges the spooled log entry which is not sent to
+// Elasticsearch, f.e. dropped by the queue overflow policy.
+func (e *es) Release(entry *LogEntry) {
+	e.spool.ack([]*LogEntry{entry})
+}
+
+// replaySpool sends log entries from the spool segments left after the
+// previous run. The segment is deleted when all its log entries are sent or
+// saved to the failover directory.
+func (e *es) replaySpool() {
+	if e.spool == nil {
+		return
+	}
+	for _, name := range e.spool.old {
+		entries, err := readSegment(name)
+		if err != nil {
+			e.l.stdout.Printf("error reading spool segment %s: %v", name, err)
+			continue
+		}
+
+		// Send log entries by batches
+		ok := true
+		for batch := range slices.Chunk(entries, e.EntriesToHold) {
+			ok = e.sendOrSave(batch) && ok
+		}
+		if ok {
+			os.Remove(name)
+		}
+	}
+	e.spool.old = nil
+}
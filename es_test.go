@@ -0,0 +1,791 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// esServer is a fake Elasticsearch server which saves bulk requests
+// documents.
+type esServer struct {
+	*httptest.Server
+	mu   sync.Mutex
+	docs []map[string]any
+	ids  map[string]bool
+
+	// resources are the created API resources by path, f.e. index templates
+	resources map[string][]byte
+	created   []string
+
+	// query is the last bulk request query
+	query string
+
+	// status returns the bulk item status of the document, if it is nil
+	// all documents are created
+	status func(doc map[string]any) int
+}
+
+// newEsServer starts a fake Elasticsearch server.
+func newEsServer(t *testing.T) *esServer {
+	s := &esServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// handle saves bulk request documents. The documents with not 2xx status and
+// the documents with already saved IDs (409 status) are not saved.
+func (s *esServer) handle(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/_bulk" {
+		s.handleResource(w, r)
+		return
+	}
+	s.mu.Lock()
+	s.query = r.URL.RawQuery
+	s.mu.Unlock()
+	gz, err := gzip.NewReader(r.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(nil, 1<<20)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var resp bulkResponse
+	var action map[string]map[string]string
+	for i := 0; scanner.Scan(); i++ {
+		if i%2 == 0 {
+			json.Unmarshal(scanner.Bytes(), &action)
+			continue
+		}
+		var doc map[string]any
+		json.Unmarshal(scanner.Bytes(), &doc)
+		doc["_index"] = action["create"]["_index"]
+		status := http.StatusCreated
+		id := action["create"]["_id"]
+		switch {
+		case id != "" && s.ids[id]:
+			status = http.StatusConflict
+		case s.status != nil:
+			status = s.status(doc)
+		}
+		if status < 300 && id != "" {
+			if s.ids == nil {
+				s.ids = make(map[string]bool)
+			}
+			s.ids[id] = true
+		}
+		item := bulkItemResp{Status: status}
+		if status < 300 {
+			s.docs = append(s.docs, doc)
+		} else {
+			resp.Errors = true
+			item.Error = json.RawMessage(`{"type":"some_exception","reason":"some reason"}`)
+		}
+		resp.Items = append(resp.Items, map[string]bulkItemResp{"create": item})
+	}
+	json.NewEncoder(w).Encode(&resp)
+}
+
+// handleResource gets and creates API resources, f.e. index templates.
+func (s *esServer) handleResource(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.resources == nil {
+		s.resources = make(map[string][]byte)
+	}
+	switch r.Method {
+	case http.MethodGet:
+		body, ok := s.resources[r.URL.Path]
+		if !ok {
+			http.Error(w, `{"status":404}`, http.StatusNotFound)
+			return
+		}
+		w.Write(body)
+	case http.MethodPut:
+		body, _ := io.ReadAll(r.Body)
+		s.resources[r.URL.Path] = body
+		s.created = append(s.created, r.URL.Path)
+		w.Write([]byte(`{"acknowledged":true}`))
+	}
+}
+
+// documents returns the saved documents.
+func (s *esServer) documents() []map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]map[string]any(nil), s.docs...)
+}
+
+func TestEsSchemaECS(t *testing.T) {
+
+	s := newEsServer(t)
+	l := New(Config{
+		AppShort:               "app",
+		AppType:                "PROD",
+		DoesNotShowInitMessage: true,
+		AddCaller:              true,
+		EsConfig: &EsConfig{
+			ES_URL:        s.URL,
+			ES_INDEX_NAME: "logs",
+			FailoverDir:   t.TempDir(),
+			Schema:        EsSchemaECS,
+		},
+	})
+	l.Warn("some message", Fields{"key": "value"})
+	l.Close()
+
+	docs := s.documents()
+	if len(docs) != 1 {
+		t.Fatalf("got %d documents, want 1", len(docs))
+	}
+	doc := docs[0]
+	hostname, _ := os.Hostname()
+	logObj, _ := doc["log"].(map[string]any)
+	service, _ := doc["service"].(map[string]any)
+	event, _ := doc["event"].(map[string]any)
+	host, _ := doc["host"].(map[string]any)
+	process, _ := doc["process"].(map[string]any)
+	switch {
+	case doc["message"] != "some message",
+		logObj["level"] != "warn",
+		logObj["origin"] == nil,
+		service["name"] != "app",
+		service["environment"] != "PROD",
+		event["dataset"] != "app",
+		host["name"] != hostname,
+		process["pid"] != float64(os.Getpid()),
+		doc["level"] != nil:
+		t.Fatalf("got wrong ECS document: %v", doc)
+	}
+}
+
+func TestEsBulkErrors(t *testing.T) {
+
+	// First bulk request: "retry" documents fail with 429 once, "reject"
+	// documents are rejected
+	s := newEsServer(t)
+	retried := false
+	s.status = func(doc map[string]any) int {
+		switch doc["message"] {
+		case "retry":
+			if !retried {
+				retried = true
+				return http.StatusTooManyRequests
+			}
+		case "reject":
+			return http.StatusBadRequest
+		}
+		return http.StatusCreated
+	}
+
+	dir := t.TempDir()
+	e := newEs(New(Config{DoesNotShowInitMessage: true}), &EsConfig{
+		ES_URL:         s.URL,
+		FailoverDir:    filepath.Join(dir, "failover"),
+		DeadLetterFile: filepath.Join(dir, "deadletter.jsonl"),
+
+		FailoverReplayInterval: time.Hour,
+	})
+	defer e.Close()
+	l := e.l
+
+	// Send batch
+	err := e.sendToElasticsearch(l.entry(LevelInfo, "ok"), l.entry(LevelInfo, "retry"),
+		l.entry(LevelInfo, "reject"))
+	bulkErr, ok := err.(*BulkError)
+	if !ok || bulkErr.Total != 3 || bulkErr.Retryable != 1 || bulkErr.Rejected != 1 {
+		t.Fatalf("got wrong bulk error: %v", err)
+	}
+
+	// Flush saves retryable entry to failover and rejected to dead-letter
+	e.entries = []*LogEntry{l.entry(LevelInfo, "retry"), l.entry(LevelInfo, "reject")}
+	s.mu.Lock()
+	retried = false
+	s.mu.Unlock()
+	e.Flush()
+	data, _ := os.ReadFile(e.DeadLetterFile)
+	if lines := strings.Split(strings.TrimSpace(string(data)), "\n"); len(lines) != 1 ||
+		!strings.Contains(lines[0], `"reason":"some reason"`) ||
+		!strings.Contains(lines[0], `"message":"reject"`) {
+		t.Fatalf("got wrong dead-letter file:\n%s", data)
+	}
+
+	// Failover file contains only retryable entry and it is sent next time
+	files, _ := filepath.Glob(filepath.Join(e.FailoverDir, "*.json"))
+	if len(files) != 1 {
+		t.Fatalf("got %d failover files, want 1", len(files))
+	}
+	e.retryFailoverFiles()
+	files, _ = filepath.Glob(filepath.Join(e.FailoverDir, "*.json"))
+	var retries int
+	for _, doc := range s.documents() {
+		if doc["message"] == "retry" {
+			retries++
+		}
+	}
+	if len(files) != 0 || retries != 1 {
+		t.Fatalf("got %d failover files and %d retried documents", len(files), retries)
+	}
+}
+
+func TestEsBreaker(t *testing.T) {
+
+	// Elasticsearch is down until the healthy flag is set
+	var requests atomic.Int32
+	var healthy atomic.Bool
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !healthy.Load() {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"errors":false,"items":[]}`))
+	}))
+	defer ts.Close()
+
+	e := newEs(New(Config{DoesNotShowInitMessage: true}), &EsConfig{
+		ES_URL:                 ts.URL,
+		FailoverDir:            t.TempDir(),
+		FailoverReplayInterval: time.Hour,
+		BreakerThreshold:       2,
+		BreakerProbeInterval:   50 * time.Millisecond,
+	})
+	defer e.Close()
+	entries := []*LogEntry{e.l.entry(LevelInfo, "message")}
+
+	// Breaker opens after two failures and does not send requests
+	e.send(entries)
+	e.send(entries)
+	if err := e.send(entries); err != ErrBreakerOpen || requests.Load() != 2 || !e.breaker.isOpen() {
+		t.Fatalf("got error %v after %d requests, want breaker open", err, requests.Load())
+	}
+
+	// Probe is sent after the probe interval and closes the breaker
+	time.Sleep(60 * time.Millisecond)
+	healthy.Store(true)
+	if err := e.send(entries); err != nil || e.breaker.isOpen() {
+		t.Fatalf("got error %v after probe, want breaker closed", err)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	b := backoff{100 * time.Millisecond, time.Second}
+	for attempt, want := range []time.Duration{
+		100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond,
+		800 * time.Millisecond, time.Second, time.Second,
+	} {
+		if d := b.delay(attempt); d < want/2 || d >= want {
+			t.Fatalf("got delay %v on attempt %d, want in [%v, %v)", d, attempt, want/2, want)
+		}
+	}
+}
+
+func TestEsSpool(t *testing.T) {
+
+	// Simulate crash: entries are written to the spool but not sent
+	dir := t.TempDir()
+	sp, err := newSpool(dir, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	l := New(Config{DoesNotShowInitMessage: true})
+	for _, msg := range []string{"lost 1", "lost 2"} {
+		if err := sp.append(l.entry(LevelInfo, msg)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sp.f.Close()
+
+	// Restarted logger sends spooled entries and new ones
+	s := newEsServer(t)
+	l = New(Config{
+		DoesNotShowInitMessage: true,
+		EsConfig: &EsConfig{
+			ES_URL:      s.URL,
+			FailoverDir: t.TempDir(),
+			SpoolDir:    dir,
+		},
+	})
+	l.Info("new")
+	l.Close()
+
+	var got []string
+	for _, doc := range s.documents() {
+		got = append(got, doc["message"].(string))
+	}
+	slices.Sort(got)
+	if !slices.Equal(got, []string{"lost 1", "lost 2", "new"}) {
+		t.Fatalf("got documents %v", got)
+	}
+
+	// All segments are acknowledged and deleted
+	if segments, _ := filepath.Glob(filepath.Join(dir, "*.seg")); len(segments) != 0 {
+		t.Fatalf("got segments left: %v", segments)
+	}
+}
+
+func TestEsSpoolOverflow(t *testing.T) {
+
+	for _, policy := range []OverflowPolicy{
+		OverflowBlock, OverflowBlockTimeout, OverflowDropNewest,
+		OverflowDropOldest, OverflowSpill,
+	} {
+		s := newEsServer(t)
+		dir := t.TempDir()
+		l := New(Config{
+			DoesNotShowInitMessage: true,
+			DropReportInterval:     -1,
+			EsConfig: &EsConfig{
+				ES_URL:        s.URL,
+				FailoverDir:   t.TempDir(),
+				EntriesToHold: 2,
+				SpoolDir:      dir,
+				Overflow: OverflowConfig{
+					Policy:   policy,
+					Timeout:  time.Microsecond,
+					SpillDir: t.TempDir(),
+				},
+			},
+		})
+		e := l.sink("elasticsearch").Sink.(*es)
+		for range 200 {
+			l.Info("message")
+		}
+		l.Close()
+
+		// Sent and dropped entries are released from the spool
+		sent, dropped := len(s.documents()), l.Dropped("elasticsearch")
+		if sent+int(dropped) != 200 {
+			t.Errorf("policy %d: got %d sent and %d dropped entries, want 200",
+				policy, sent, dropped)
+		}
+		if n := len(e.spool.entries); n != 0 {
+			t.Errorf("policy %d: got %d entries left in spool", policy, n)
+		}
+		if segments, _ := filepath.Glob(filepath.Join(dir, "*.seg")); len(segments) != 0 {
+			t.Errorf("policy %d: got segments left: %v", policy, segments)
+		}
+	}
+}
+
+func TestSpoolSegments(t *testing.T) {
+
+	// Every entry is written to its own segment
+	dir := t.TempDir()
+	sp, _ := newSpool(dir, 1, true)
+	l := New(Config{DoesNotShowInitMessage: true})
+	entries := []*LogEntry{l.entry(LevelInfo, "1"), l.entry(LevelInfo, "2")}
+	for _, entry := range entries {
+		sp.append(entry)
+	}
+	segments := func() int {
+		list, _ := filepath.Glob(filepath.Join(dir, "*.seg"))
+		return len(list)
+	}
+	if n := segments(); n != 3 {
+		t.Fatalf("got %d segments, want 3", n)
+	}
+
+	// Acknowledged segments are deleted, the current one is deleted on close
+	sp.ack(entries[:1])
+	if n := segments(); n != 2 {
+		t.Fatalf("got %d segments after ack, want 2", n)
+	}
+	sp.ack(entries[1:])
+	sp.close()
+	if n := segments(); n != 0 {
+		t.Fatalf("got %d segments after close, want 0", n)
+	}
+}
+
+func TestEsIdempotent(t *testing.T) {
+
+	s := newEsServer(t)
+	e := newEs(New(Config{DoesNotShowInitMessage: true}), &EsConfig{
+		ES_URL:                 s.URL,
+		FailoverDir:            t.TempDir(),
+		FailoverReplayInterval: time.Hour,
+	})
+	defer e.Close()
+	entries := []*LogEntry{e.l.entry(LevelInfo, "1"), e.l.entry(LevelInfo, "2")}
+
+	// Replayed entries conflict with existing documents and are not
+	// duplicated
+	if err := e.sendToElasticsearch(entries[:1]...); err != nil {
+		t.Fatal(err)
+	}
+	if err := e.sendToElasticsearch(entries...); err != nil {
+		t.Fatalf("got error on replay: %v", err)
+	}
+	if docs := s.documents(); len(docs) != 2 || docs[1]["id"] != entries[1].ID {
+		t.Fatalf("got documents %v", docs)
+	}
+}
+
+func TestNewID(t *testing.T) {
+	now := time.Now()
+	a, b := newID(now), newID(now.Add(time.Millisecond))
+	if len(a) != 26 || len(b) != 26 || a == b || a >= b {
+		t.Fatalf("got wrong ids: %s, %s", a, b)
+	}
+	if strings.Trim(a, crockford) != "" {
+		t.Fatalf("got id with wrong characters: %s", a)
+	}
+	if c := newID(now); c[:10] != a[:10] || c == a {
+		t.Fatalf("got wrong id in the same millisecond: %s, %s", a, c)
+	}
+}
+
+func TestEsBootstrap(t *testing.T) {
+
+	s := newEsServer(t)
+	s.resources = map[string][]byte{"/_ilm/policy/logs-policy": []byte(`{}`)}
+	for range 2 {
+		l := New(Config{
+			DoesNotShowInitMessage: true,
+			EsConfig: &EsConfig{
+				ES_URL:        s.URL,
+				ES_INDEX_NAME: "logs",
+				FailoverDir:   t.TempDir(),
+				Bootstrap:     &EsBootstrap{DeleteAfter: "7d"},
+			},
+		})
+		l.Info("message")
+		l.Close()
+	}
+
+	// Existing policy is not changed, template and data stream are created
+	// once
+	want := []string{"/_index_template/logs", "/_data_stream/logs"}
+	if !slices.Equal(s.created, want) {
+		t.Fatalf("got created %v, want %v", s.created, want)
+	}
+	var template map[string]any
+	json.Unmarshal(s.resources["/_index_template/logs"], &template)
+	settings := template["template"].(map[string]any)["settings"].(map[string]any)
+	if settings["index.lifecycle.name"] != "logs-policy" || template["data_stream"] == nil {
+		t.Fatalf("got wrong template: %v", template)
+	}
+	if patterns := template["index_patterns"].([]any); len(patterns) != 1 || patterns[0] != "logs" {
+		t.Fatalf("got index patterns %v, want [logs]", patterns)
+	}
+	if len(s.documents()) != 2 {
+		t.Fatalf("got %d documents, want 2", len(s.documents()))
+	}
+
+	// Index name template without literal prefix is not bootstrapped
+	s = newEsServer(t)
+	l := New(Config{
+		DoesNotShowInitMessage: true,
+		EsConfig: &EsConfig{
+			ES_URL:        s.URL,
+			ES_INDEX_NAME: "{level}-logs",
+			FailoverDir:   t.TempDir(),
+			Bootstrap:     &EsBootstrap{TemplateName: "logs"},
+		},
+	})
+	l.Info("message")
+	l.Close()
+	if len(s.created) != 0 || len(s.documents()) != 1 {
+		t.Fatalf("got created %v and %d documents", s.created, len(s.documents()))
+	}
+
+	// Index name template with time placeholders makes application specific
+	// regular indexes deleted by ILM policy without rollover
+	s = newEsServer(t)
+	l = New(Config{
+		AppShort:               "myapp",
+		AppType:                "PROD",
+		DoesNotShowInitMessage: true,
+		EsConfig: &EsConfig{
+			ES_URL:        s.URL,
+			ES_INDEX_NAME: "logs-{app}-{env}-{2006.01.02}",
+			FailoverDir:   t.TempDir(),
+			Bootstrap:     &EsBootstrap{},
+		},
+	})
+	l.Info("message")
+	l.Close()
+	want = []string{"/_ilm/policy/logs-myapp-prod-policy", "/_index_template/logs-myapp-prod"}
+	if !slices.Equal(s.created, want) {
+		t.Fatalf("got created %v, want %v", s.created, want)
+	}
+	template = nil
+	json.Unmarshal(s.resources["/_index_template/logs-myapp-prod"], &template)
+	if patterns := template["index_patterns"].([]any); len(patterns) != 1 ||
+		patterns[0] != "logs-myapp-prod-*" || template["data_stream"] != nil {
+		t.Fatalf("got wrong template: %v", template)
+	}
+	if policy := string(s.resources["/_ilm/policy/logs-myapp-prod-policy"]); strings.Contains(policy, "rollover") {
+		t.Fatalf("got rollover in policy: %s", policy)
+	}
+
+	// Index name template starting with {app} is bootstrapped
+	s = newEsServer(t)
+	l = New(Config{
+		AppShort:               "myapp",
+		DoesNotShowInitMessage: true,
+		EsConfig: &EsConfig{
+			ES_URL:        s.URL,
+			ES_INDEX_NAME: "{app}-logs-{level}",
+			FailoverDir:   t.TempDir(),
+			Bootstrap:     &EsBootstrap{},
+		},
+	})
+	l.Info("message")
+	l.Close()
+	want = []string{"/_ilm/policy/myapp-logs-policy", "/_index_template/myapp-logs"}
+	if !slices.Equal(s.created, want) {
+		t.Fatalf("got created %v, want %v", s.created, want)
+	}
+
+	// Rejected ingest pipeline does not stop the bootstrap
+	s = &esServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/_ingest/pipeline/") {
+			http.Error(w, `{"error":"invalid processor"}`, http.StatusBadRequest)
+			return
+		}
+		s.handle(w, r)
+	}))
+	defer s.Close()
+	l = New(Config{
+		DoesNotShowInitMessage: true,
+		EsConfig: &EsConfig{
+			ES_URL:         s.URL,
+			ES_INDEX_NAME:  "logs",
+			FailoverDir:    t.TempDir(),
+			Pipeline:       "logs",
+			IngestPipeline: &EsPipeline{},
+			Bootstrap:      &EsBootstrap{},
+		},
+	})
+	l.Info("message")
+	l.Close()
+	want = []string{"/_ilm/policy/logs-policy", "/_index_template/logs", "/_data_stream/logs"}
+	if !slices.Equal(s.created, want) {
+		t.Fatalf("got created %v, want %v", s.created, want)
+	}
+}
+
+func TestEsIndexTemplate(t *testing.T) {
+
+	tmpl, err := parseIndexTemplate("logs-{app}-{env}-{fields.http.method}-{2006.01.02}", "App", "PROD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	entry := &LogEntry{
+		Timestamp: "2025-10-19T01:28:50+03:00",
+		Fields:    Fields{"http": Fields{"method": "GET"}},
+	}
+	if index := tmpl.expand(entry); index != "logs-app-prod-get-2025.10.18" {
+		t.Fatalf("got index %s", index)
+	}
+	if p, name := tmpl.pattern(), tmpl.bootstrapName(); p != "logs-app-prod-*-*" || name != "logs-app-prod" {
+		t.Fatalf("got pattern %s and name %s", p, name)
+	}
+	if _, err := parseIndexTemplate("logs-{app", "", ""); err == nil {
+		t.Fatal("invalid template parsed without error")
+	}
+
+	// Batch is grouped by index in the bulk request
+	s := newEsServer(t)
+	l := New(Config{
+		AppShort:               "app",
+		DoesNotShowInitMessage: true,
+		EsConfig: &EsConfig{
+			ES_URL:        s.URL,
+			ES_INDEX_NAME: "logs-{app}-{level}",
+			FailoverDir:   t.TempDir(),
+		},
+	})
+	l.Info("1")
+	l.Warn("2")
+	l.Info("3")
+	l.Close()
+
+	var got []string
+	for _, doc := range s.documents() {
+		got = append(got, doc["_index"].(string)+":"+doc["message"].(string))
+	}
+	want := []string{"logs-app-info:1", "logs-app-info:3", "logs-app-warn:2"}
+	if !slices.Equal(got, want) {
+		t.Fatalf("got documents %v, want %v", got, want)
+	}
+}
+
+func TestEsClient(t *testing.T) {
+
+	// TLS server which checks basic auth and custom header
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "user" || pass != "pass" || r.Header.Get("X-Custom") != "value" {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer ts.Close()
+	caCert := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ts.Certificate().Raw})
+
+	l := New(Config{DoesNotShowInitMessage: true})
+	for _, test := range []struct {
+		name   string
+		config EsConfig
+		status int
+	}{
+		{"ca", EsConfig{CACert: caCert}, http.StatusOK},
+		{"insecure", EsConfig{InsecureSkipVerify: true}, http.StatusOK},
+		{"client", EsConfig{HTTPClient: ts.Client()}, http.StatusOK},
+		{"transport", EsConfig{Transport: ts.Client().Transport}, http.StatusOK},
+		{"unknown ca", EsConfig{}, 0},
+		{"api key", EsConfig{ES_API_KEY: "key", HTTPClient: ts.Client()}, http.StatusUnauthorized},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			config := test.config
+			config.ES_URL = ts.URL
+			config.FailoverDir = t.TempDir()
+			config.Headers = map[string]string{"X-Custom": "value"}
+			if config.ES_API_KEY == "" {
+				config.Username, config.Password = "user", "pass"
+			}
+			e := newEs(l, &config)
+			defer e.Close()
+
+			status, _, err := e.doJSON("GET", "/", nil)
+			if status != test.status || (status == 0) != (err != nil) {
+				t.Fatalf("got status %d, error %v, want status %d", status, err, test.status)
+			}
+		})
+	}
+}
+
+func TestEsPipeline(t *testing.T) {
+
+	s := newEsServer(t)
+	l := New(Config{
+		DoesNotShowInitMessage: true,
+		EsConfig: &EsConfig{
+			ES_URL:      s.URL,
+			FailoverDir: t.TempDir(),
+			Pipeline:    "logs",
+			Refresh:     "wait_for",
+			IngestPipeline: &EsPipeline{
+				Processors: []EsProcessor{UserAgentProcessor("fields.ua", "user_agent")},
+			},
+		},
+	})
+	l.Info("message")
+	l.Close()
+
+	if s.query != "pipeline=logs&refresh=wait_for" {
+		t.Fatalf("got bulk query %q", s.query)
+	}
+	var pipeline EsPipeline
+	json.Unmarshal(s.resources["/_ingest/pipeline/logs"], &pipeline)
+	if len(pipeline.Processors) != 1 || pipeline.Processors[0]["user_agent"] == nil {
+		t.Fatalf("got wrong pipeline: %s", s.resources["/_ingest/pipeline/logs"])
+	}
+}
+
+func TestEsNodes(t *testing.T) {
+
+	// One dead node and two alive nodes
+	dead := httptest.NewServer(http.NotFoundHandler())
+	dead.Close()
+	s1, s2 := newEsServer(t), newEsServer(t)
+	e := newEs(New(Config{DoesNotShowInitMessage: true}), &EsConfig{
+		ES_URL:                 dead.URL,
+		Nodes:                  []string{s1.URL, s2.URL},
+		FailoverDir:            t.TempDir(),
+		FailoverReplayInterval: time.Hour,
+		HealthCheckInterval:    -1,
+	})
+	defer e.Close()
+
+	// Requests are balanced between alive nodes and the dead node is
+	// skipped
+	for i := range 4 {
+		if err := e.sendToElasticsearch(e.l.entry(LevelInfo, "message")); err != nil {
+			t.Fatalf("got error on request %d: %v", i, err)
+		}
+	}
+	if n1, n2 := len(s1.documents()), len(s2.documents()); n1 != 2 || n2 != 2 {
+		t.Fatalf("got %d and %d documents, want 2 and 2", n1, n2)
+	}
+	if deadNodes := e.nodes.deadNodes(); len(deadNodes) != 1 || deadNodes[0].url != dead.URL {
+		t.Fatalf("got dead nodes %v", deadNodes)
+	}
+
+	// Sniffed nodes replace configured nodes
+	addr := strings.TrimPrefix(s2.URL, "http://")
+	for _, s := range []*esServer{s1, s2} {
+		s.mu.Lock()
+		s.resources = map[string][]byte{"/_nodes/http": []byte(
+			`{"nodes":{"n2":{"http":{"publish_address":"` + addr + `"}}}}`)}
+		s.mu.Unlock()
+	}
+	e.sniff()
+	if urls := e.nodes.urls(); len(urls) != 1 || urls[0] != s2.URL {
+		t.Fatalf("got sniffed nodes %v", urls)
+	}
+}
+
+func TestNodePool(t *testing.T) {
+
+	// Dead node is skipped until it is resurrected
+	p := newNodePool([]string{"a", "b", "a", ""}, 20*time.Millisecond)
+	if urls := p.urls(); !slices.Equal(urls, []string{"a", "b"}) {
+		t.Fatalf("got nodes %v", urls)
+	}
+	a := p.pick()
+	p.dead(a)
+	for range 3 {
+		if n := p.pick(); n.url != "b" {
+			t.Fatalf("got dead node %s", n.url)
+		}
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := p.pick(); n.url != "a" {
+		t.Fatalf("got node %s, want resurrected node a", n.url)
+	}
+
+	// The first resurrected node is used if all nodes are dead
+	p.dead(p.nodes[1])
+	p.dead(p.nodes[0])
+	p.dead(p.nodes[0])
+	if n := p.pick(); n.url != "b" {
+		t.Fatalf("got node %s, want b", n.url)
+	}
+
+	// Publish addresses
+	for address, want := range map[string]string{
+		"10.0.0.1:9200":           "https://10.0.0.1:9200",
+		"es1.local/10.0.0.1:9200": "https://es1.local:9200",
+		"[::1]:9200":              "https://[::1]:9200",
+		"invalid":                 "",
+	} {
+		if got := nodeURL("https", address); got != want {
+			t.Errorf("nodeURL(%q) = %q, want %q", address, got, want)
+		}
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"compress/gzip"
 	"fmt"
 	"io"
-	"log"
 	"os"
 	"time"
 )
@@ -17,22 +16,32 @@ type FileConfig struct {
 
 	// Create new log file after
 	CreateNewAfter time.Duration
+
+	// Minimum log level written to file. If not set, all log levels are
+	// written.
+	MinLevel LogLevel
+
+	// Formatter of the log file lines, f.e. JSONFormatter{} or
+	// LogfmtFormatter{}. If nil, the TextFormatter is used.
+	Formatter Formatter
+
+	// Queue overflow configuration. If not set, log calls block while the
+	// file sink queue is full.
+	Overflow OverflowConfig
 }
 
-// file is a struct that holds information about how to send log entries to a
-// file.
+// file is a Sink that writes log entries to a file.
 type file struct {
 
-	// fileEntryChannel is a channel that receives log entries for sending to
-	// file
-	fileEntryChannel chan *LogEntry
-
 	// File log parameters
 	*FileConfig
 
 	// Application short name
 	AppShort string
 
+	// Logger which owns this file logger
+	l *Logger
+
 	// Current opened log file
 	f *os.File
 
@@ -40,74 +49,71 @@ type file struct {
 	fCreatedAt time.Time
 }
 
-// init sets up the file logger and starts the entry handler goroutine.
-func (f *file) init(appShort string, fileConfig *FileConfig) {
-	if fileConfig == nil {
-		return
-	}
+// newFile creates the file sink.
+func newFile(l *Logger, fileConfig *FileConfig) *file {
+	return &file{FileConfig: fileConfig, AppShort: l.appShort, l: l}
+}
 
-	// Set file logger config
-	f.FileConfig = fileConfig
-	f.AppShort = appShort
+// Name returns the file sink name.
+func (f *file) Name() string { return "file" }
 
-	// Create entry channel
-	f.fileEntryChannel = make(chan *LogEntry, 100)
+// MinLevel returns the file sink minimum log level.
+func (f *file) MinLevel() LogLevel { return f.FileConfig.MinLevel }
 
-	// Start entry handler
-	loggers.wgStart.Add(1)
-	go f.entryHandler()
-}
+// Overflow returns the file sink queue overflow configuration.
+func (f *file) Overflow() OverflowConfig { return f.FileConfig.Overflow }
 
-// close closes the entry channel and stop the entry processing goroutine.
-func (f *file) close() {
-	close(f.fileEntryChannel)
-}
+// Write writes log entry to the file. It either creates a new file, or
+// switches to a new file after a certain time period. Then it writes the log
+// entry to the file.
+func (f *file) Write(entry *LogEntry) (err error) {
 
-// entryHandler is a goroutine that consumes log entries from the fileEntryChannel.
-// It checks if the log entry channel is closed, and if so, it exits the goroutine.
-// It then either creates a new file, or switches to a new file after a certain
-// time period. Finally, it sends the log entries to file.
-func (f *file) entryHandler() {
-	loggers.wgStart.Done()
-
-	loggers.wgClose.Add(1)
-	defer loggers.wgClose.Done()
-
-	// Loop until the goroutine is stopped
-	for {
-		entry, ok := <-f.fileEntryChannel
-		if !ok {
-			// If the channel is closed, exit the goroutine
-			break
-		}
+	// Set or change file
+	switch f.f {
 
-		// Set or change file
-		var err error
+	// Create new file
+	case nil:
+		err = f.newLogfile()
 
-		switch f.f {
+	// Switch file
+	default:
+		// If file log created more than CreateNewAfter ago
+		if f.CreateNewAfter > 0 && time.Since(f.fCreatedAt) > f.CreateNewAfter {
+			// Close current file
+			f.f.Close()
 
-		// Create new file
-		case nil:
+			// Create new file
 			err = f.newLogfile()
+		}
+	}
+	if err != nil {
+		return
+	}
 
-		// Switch file
-		default:
-			// If file log created more than 10 minutes ago
-			if f.CreateNewAfter > 0 && time.Since(f.fCreatedAt) > f.CreateNewAfter {
-				// Close current file
-				f.f.Close()
+	// Format log entry
+	formatter := f.FileConfig.Formatter
+	if formatter == nil {
+		formatter = TextFormatter{}
+	}
+	data, err := formatter.Format(entry)
+	if err != nil {
+		return
+	}
 
-				// Create new file
-				err = f.newLogfile()
-			}
-		}
-		if err != nil {
-			continue
-		}
+	// Send to file
+	_, err = f.f.Write(append(data, '\n'))
+	return
+}
+
+// Flush does nothing, the log entries are written to the file immediately.
+func (f *file) Flush() error { return nil }
 
-		// Send to file
-		f.f.Write([]byte(entry.String() + "\n"))
+// Close closes current log file.
+func (f *file) Close() error {
+	if f.f == nil {
+		return nil
 	}
+	return f.f.Close()
 }
 
 // newLogfile creates a new log file and switches the file logger to it.
@@ -136,7 +142,7 @@ func (f *file) newLogfile() (err error) {
 	fileName := fmt.Sprintf("%s/%s_%s.log", folder, f.AppShort, timeStr)
 	file, err := os.OpenFile(fileName, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
 	if err != nil {
-		fmt.Println("error creating log file:", err)
+		err = fmt.Errorf("error creating log file: %w", err)
 		return
 	}
 
@@ -153,7 +159,7 @@ func (f *file) newLogfile() (err error) {
 	// Set new file
 	f.f = file
 	f.fCreatedAt = now
-	log.Println("create new log file:", file.Name())
+	f.l.stdout.Println("create new log file:", file.Name())
 	return
 }
 
@@ -0,0 +1,259 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Formatter formats log entries for the text sinks, f.e. stdout and file.
+//
+// The built-in formatters are TextFormatter, JSONFormatter, LogfmtFormatter
+// and ConsoleFormatter. The Format result is one log line without the
+// trailing newline, the sink adds it.
+type Formatter interface {
+	Format(entry *LogEntry) ([]byte, error)
+}
+
+// FormatterFunc is an adapter to use ordinary function as Formatter.
+type FormatterFunc func(entry *LogEntry) ([]byte, error)
+
+// Format calls f(entry).
+func (f FormatterFunc) Format(entry *LogEntry) ([]byte, error) { return f(entry) }
+
+// TextFormatter formats log entries in the default text layout, the same as
+// LogEntry.String returns:
+//
+//	2025-10-19T10:28:50.567024+03:00     [INFO] message, fields: key=value
+type TextFormatter struct{}
+
+// Format formats log entry in the default text layout.
+func (TextFormatter) Format(entry *LogEntry) ([]byte, error) {
+	return []byte(entry.String()), nil
+}
+
+// JSONFormatter formats log entries as JSON lines, one JSON object per log
+// entry, with the same keys as the Elasticsearch documents have.
+type JSONFormatter struct{}
+
+// Format formats log entry as JSON object.
+func (JSONFormatter) Format(entry *LogEntry) ([]byte, error) {
+	e := *entry
+	e.Message = strings.Trim(e.Message, "\n")
+	return json.Marshal(&e)
+}
+
+// LogfmtFormatter formats log entries in logfmt format, f.e.:
+//
+//	time=2025-10-19T10:28:50.567024+03:00 level=INFO msg="some message" key=value
+//
+// The log entry fields are added after the basic keys in sorted order.
+type LogfmtFormatter struct{}
+
+// Format formats log entry in logfmt format.
+func (LogfmtFormatter) Format(entry *LogEntry) ([]byte, error) {
+	var b strings.Builder
+
+	// Add basic keys
+	logfmtPair(&b, "time", entry.Timestamp)
+	if entry.Level != LevelNone {
+		logfmtPair(&b, "level", string(entry.Level))
+	}
+	if entry.AppType != "" {
+		logfmtPair(&b, "app_type", entry.AppType)
+	}
+	if entry.Caller != nil {
+		logfmtPair(&b, "caller", entry.Caller.String())
+	}
+	logfmtPair(&b, "msg", strings.Trim(entry.Message, "\n"))
+	if entry.TraceID != "" {
+		logfmtPair(&b, "trace.id", entry.TraceID)
+		logfmtPair(&b, "span.id", entry.SpanID)
+	}
+
+	// Add fields
+	if len(entry.Fields) > 0 {
+		b.WriteByte(' ')
+		appendFields(&b, entry.Fields)
+	}
+
+	// Add error and stack trace
+	if entry.Error != nil {
+		logfmtPair(&b, "error", entry.Error.Message)
+	}
+	if entry.Stack != "" {
+		logfmtPair(&b, "stack_trace", entry.Stack)
+	}
+
+	return []byte(b.String()), nil
+}
+
+// ConsoleFormatter formats log entries for the terminal: short time, colored
+// level, caller, message and fields. Use it with the stdout sink when stdout
+// is a TTY, the colors are ANSI escape sequences.
+type ConsoleFormatter struct {
+	// NoColor disables the colors
+	NoColor bool
+
+	// TimeFormat is a time layout, if empty "15:04:05.000" is used
+	TimeFormat string
+}
+
+// ANSI colors used by ConsoleFormatter
+const (
+	colorReset  = "\x1b[0m"
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorBlue   = "\x1b[34m"
+	colorGray   = "\x1b[90m"
+)
+
+// Format formats log entry for the terminal.
+func (c ConsoleFormatter) Format(entry *LogEntry) ([]byte, error) {
+	var b strings.Builder
+
+	// Add short time, use the entry timestamp as is if it is not RFC3339
+	timeFormat := c.TimeFormat
+	if timeFormat == "" {
+		timeFormat = "15:04:05.000"
+	}
+	timestamp := entry.Timestamp
+	if t, err := time.Parse(time.RFC3339Nano, timestamp); err == nil {
+		timestamp = t.Format(timeFormat)
+	}
+	b.WriteString(c.color(colorGray, timestamp))
+
+	// Add colored level
+	if entry.Level != LevelNone {
+		b.WriteByte(' ')
+		b.WriteString(c.color(levelColor(entry.Level), fmt.Sprintf("%-5s", entry.Level)))
+	}
+
+	// Add caller and message
+	if entry.Caller != nil {
+		b.WriteByte(' ')
+		b.WriteString(c.color(colorGray, entry.Caller.String()))
+	}
+	b.WriteByte(' ')
+	b.WriteString(escapeLine(strings.Trim(entry.Message, "\n")))
+
+	// Add fields, trace context and error
+	for _, f := range flattenFields(entry.Fields) {
+		b.WriteByte(' ')
+		b.WriteString(c.color(colorGray, logfmtValue(f.key)+"="))
+		b.WriteString(logfmtValue(textValue(f.value)))
+	}
+	if entry.TraceID != "" {
+		b.WriteString(c.color(colorGray, " trace.id="+entry.TraceID+" span.id="+entry.SpanID))
+	}
+	if entry.Error != nil {
+		b.WriteString(" " + c.color(colorRed, "error="+logfmtValue(entry.Error.Message)))
+	}
+
+	// Add stack trace on the next lines
+	if entry.Stack != "" {
+		b.WriteString("\n" + c.color(colorGray, strings.TrimRight(entry.Stack, "\n")))
+	}
+
+	return []byte(b.String()), nil
+}
+
+// color returns s colored with ANSI color if colors are not disabled.
+func (c ConsoleFormatter) color(color, s string) string {
+	if c.NoColor {
+		return s
+	}
+	return color + s + colorReset
+}
+
+// levelColor returns the ANSI color of the log level.
+func levelColor(level LogLevel) string {
+	switch rank := level.rank(); {
+	case rank >= LevelError.rank():
+		return colorRed
+	case rank == LevelWarn.rank():
+		return colorYellow
+	case rank == LevelInfo.rank():
+		return colorGreen
+	case rank == LevelDebug.rank():
+		return colorBlue
+	default:
+		return colorGray
+	}
+}
+
+// writerSink is a Sink which writes formatted log entries to io.Writer.
+type writerSink struct {
+	name      string
+	w         io.Writer
+	formatter Formatter
+}
+
+// NewWriterSink returns a Sink which writes log entries formatted with the
+// formatter to w, one log entry per line. If formatter is nil, the
+// TextFormatter is used. The Sink is closed with w if w is io.Closer.
+func NewWriterSink(name string, w io.Writer, formatter Formatter) Sink {
+	if formatter == nil {
+		formatter = TextFormatter{}
+	}
+	return &writerSink{name, w, formatter}
+}
+
+// Name returns the writer sink name.
+func (s *writerSink) Name() string { return s.name }
+
+// Write formats log entry and writes it to the writer.
+func (s *writerSink) Write(entry *LogEntry) error {
+	data, err := s.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	_, err = s.w.Write(append(data, '\n'))
+	return err
+}
+
+// Flush does nothing, the log entries are written to the writer immediately.
+func (s *writerSink) Flush() error { return nil }
+
+// Close closes the writer if it is io.Closer.
+func (s *writerSink) Close() error {
+	if c, ok := s.w.(io.Closer); ok {
+		return c.Close()
+	}
+	return nil
+}
+
+// logfmtPair writes " key=value" to b, the leading space is omitted for the
+// first pair.
+func logfmtPair(b *strings.Builder, key, value string) {
+	if b.Len() > 0 {
+		b.WriteByte(' ')
+	}
+	b.WriteString(key)
+	b.WriteByte('=')
+	b.WriteString(logfmtValue(value))
+}
+
+// logfmtValue returns the value quoted if it is empty or contains spaces,
+// quotes, equal signs or control characters.
+func logfmtValue(value string) string {
+	if value == "" {
+		return `""`
+	}
+	if strings.IndexFunc(value, func(r rune) bool {
+		return r <= ' ' || r == '=' || r == '"' || r == unicode.ReplacementChar ||
+			unicode.IsSpace(r) || unicode.IsControl(r)
+	}) >= 0 {
+		return strconv.Quote(value)
+	}
+	return value
+}
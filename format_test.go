@@ -0,0 +1,71 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFormatters(t *testing.T) {
+
+	entry := &LogEntry{
+		AppType:   "TEST",
+		Timestamp: "2025-10-19T10:28:50.567024+03:00",
+		Level:     LevelInfo,
+		Message:   "some message\n",
+		Fields:    Fields{"b": 2, "a": "with space"},
+		Error:     newEntryError(errors.New("failed")),
+	}
+
+	// Logfmt
+	data, err := LogfmtFormatter{}.Format(entry)
+	want := `time=2025-10-19T10:28:50.567024+03:00 level=INFO app_type=TEST ` +
+		`msg="some message" a="with space" b=2 error=failed`
+	if err != nil || string(data) != want {
+		t.Fatalf("got logfmt:\n%s\nwant:\n%s", data, want)
+	}
+
+	// JSON lines
+	data, err = JSONFormatter{}.Format(entry)
+	var m map[string]any
+	if err != nil || json.Unmarshal(data, &m) != nil || m["message"] != "some message" ||
+		bytes.ContainsRune(data, '\n') {
+		t.Fatalf("got wrong json: %s, %v", data, err)
+	}
+
+	// Console without colors
+	data, err = ConsoleFormatter{NoColor: true}.Format(entry)
+	want = `10:28:50.567 INFO  some message a="with space" b=2 error=failed`
+	if err != nil || string(data) != want {
+		t.Fatalf("got console:\n%s\nwant:\n%s", data, want)
+	}
+
+	// Console with colors
+	data, _ = ConsoleFormatter{}.Format(entry)
+	if !strings.Contains(string(data), colorGreen+"INFO ") {
+		t.Fatalf("got console without colors: %q", data)
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+
+	var buf bytes.Buffer
+	l := New(Config{
+		DoesNotShowInitMessage: true,
+		Sinks:                  []Sink{NewWriterSink("buf", &buf, LogfmtFormatter{})},
+	})
+	l.Info("one")
+	l.Info("two", Fields{"key": "value"})
+	l.Close()
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[1], "msg=two key=value") {
+		t.Fatalf("got wrong output:\n%s", buf.String())
+	}
+}
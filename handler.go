@@ -0,0 +1,195 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// LevelHandler is an http.Handler which reports and changes the Logger log
+// levels at runtime. It may be mounted on any http.ServeMux, f.e.:
+//
+//	mux.Handle("/log/level", log.NewLevelHandler(nil))
+//
+// The GET request returns current levels as JSON:
+//
+//	{"default_level":"DEBUG","min_level":"NONE","sinks":{"stdout":"INFO"}}
+//
+// The PUT request changes levels which are present in the JSON body and
+// returns new levels. The "revert_after" field sets the time after which the
+// levels are reverted to their values before this request, f.e.:
+//
+//	{"min_level":"DEBUG","sinks":{"file":"DEBUG"},"revert_after":"15m"}
+//
+// The "NONE" level (or empty string) means no level. A new PUT request
+// cancels a pending revert. If the new request has "revert_after" too, the
+// levels are reverted to the values before the first request.
+type LevelHandler struct {
+	l *Logger
+
+	// Mutex protects revert timer and levels saved to revert
+	mu sync.Mutex
+
+	// revert is a pending revert timer
+	revert *time.Timer
+
+	// revertAt is a pending revert time
+	revertAt time.Time
+
+	// saved is a levels to revert to
+	saved *levelsState
+}
+
+// levelsState is a Logger levels in JSON requests and responses.
+type levelsState struct {
+	DefaultLevel *string           `json:"default_level,omitempty"`
+	MinLevel     *string           `json:"min_level,omitempty"`
+	Sinks        map[string]string `json:"sinks,omitempty"`
+	RevertAfter  string            `json:"revert_after,omitempty"`
+	RevertAt     *time.Time        `json:"revert_at,omitempty"`
+}
+
+// NewLevelHandler returns a new LevelHandler for the Logger l. If l is nil,
+// the default Logger is used.
+func NewLevelHandler(l *Logger) *LevelHandler {
+	if l == nil {
+		l = Default()
+	}
+	return &LevelHandler{l: l}
+}
+
+// ServeHTTP implements http.Handler interface.
+func (h *LevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		h.writeLevels(w)
+
+	case http.MethodPut:
+		var req levelsState
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "invalid request: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := h.setLevels(&req); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		h.writeLevels(w)
+
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+// writeLevels writes current levels as JSON response.
+func (h *LevelHandler) writeLevels(w http.ResponseWriter) {
+	h.mu.Lock()
+	state := h.levels()
+	if h.revert != nil {
+		revertAt := h.revertAt
+		state.RevertAt = &revertAt
+	}
+	h.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(state)
+}
+
+// levels returns current Logger levels.
+func (h *LevelHandler) levels() *levelsState {
+	defaultLevel := levelName(h.l.DefaultLevel())
+	minLevel := levelName(h.l.MinLevel())
+	state := &levelsState{
+		DefaultLevel: &defaultLevel,
+		MinLevel:     &minLevel,
+		Sinks:        make(map[string]string, len(h.l.sinks)),
+	}
+	for _, sink := range h.l.sinks {
+		state.Sinks[sink.Name()] = levelName(sink.minLevel.Level())
+	}
+	return state
+}
+
+// setLevels validates and sets levels from the request and schedules their
+// revert if requested.
+func (h *LevelHandler) setLevels(req *levelsState) (err error) {
+
+	// Parse revert timeout
+	var revertAfter time.Duration
+	if req.RevertAfter != "" {
+		revertAfter, err = time.ParseDuration(req.RevertAfter)
+		if err != nil || revertAfter <= 0 {
+			return fmt.Errorf("invalid revert_after: %q", req.RevertAfter)
+		}
+	}
+
+	// Validate levels before changing anything
+	for _, level := range []*string{req.DefaultLevel, req.MinLevel} {
+		if level != nil && !validLevel(*level) {
+			return fmt.Errorf("unknown level: %q", *level)
+		}
+	}
+	for name, level := range req.Sinks {
+		if h.l.sink(name) == nil {
+			return fmt.Errorf("sink %q not found", name)
+		}
+		if !validLevel(level) {
+			return fmt.Errorf("unknown level: %q", level)
+		}
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	// Cancel pending revert, keep levels saved before the first request
+	saved := h.levels()
+	if h.revert != nil {
+		h.revert.Stop()
+		h.revert = nil
+		saved = h.saved
+	}
+	h.saved = nil
+
+	// Set levels
+	h.apply(req)
+
+	// Schedule revert
+	if revertAfter > 0 {
+		h.saved = saved
+		h.revertAt = time.Now().Add(revertAfter)
+		var timer *time.Timer
+		timer = time.AfterFunc(revertAfter, func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			if h.revert != timer {
+				return
+			}
+			h.apply(h.saved)
+			h.revert, h.saved = nil, nil
+			h.l.Infof("log levels reverted after %v", revertAfter)
+		})
+		h.revert = timer
+	}
+
+	return
+}
+
+// apply sets Logger levels from the state.
+func (h *LevelHandler) apply(state *levelsState) {
+	if state.DefaultLevel != nil {
+		h.l.SetDefaultLevel(parseLevel(*state.DefaultLevel))
+	}
+	if state.MinLevel != nil {
+		h.l.SetMinLevel(parseLevel(*state.MinLevel))
+	}
+	for name, level := range state.Sinks {
+		h.l.SetSinkMinLevel(name, parseLevel(level))
+	}
+}
@@ -0,0 +1,81 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLevelHandler(t *testing.T) {
+
+	l := New(Config{DoesNotShowInitMessage: true, Sinks: []Sink{&memSink{}}})
+	defer l.Close()
+	h := NewLevelHandler(l)
+
+	// request sends request to the handler and returns decoded response
+	request := func(method, body string, code int) (state levelsState) {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, "/", strings.NewReader(body)))
+		if w.Code != code {
+			t.Fatalf("%s %s: got status %d, want %d", method, body, w.Code, code)
+		}
+		if code == http.StatusOK {
+			json.NewDecoder(w.Body).Decode(&state)
+		}
+		return
+	}
+
+	// Get levels
+	state := request(http.MethodGet, "", http.StatusOK)
+	if *state.DefaultLevel != "DEBUG" || *state.MinLevel != "NONE" ||
+		state.Sinks["mem"] != "NONE" {
+		t.Fatalf("got wrong levels: %+v", state)
+	}
+
+	// Set wrong levels
+	request(http.MethodPut, `{"min_level":"LOUD"}`, http.StatusBadRequest)
+	request(http.MethodPut, `{"sinks":{"unknown":"INFO"}}`, http.StatusBadRequest)
+	request(http.MethodPost, `{}`, http.StatusMethodNotAllowed)
+
+	// Set levels with revert
+	state = request(http.MethodPut,
+		`{"min_level":"warn","sinks":{"mem":"ERROR"},"revert_after":"50ms"}`,
+		http.StatusOK)
+	if *state.MinLevel != "WARN" || state.Sinks["mem"] != "ERROR" ||
+		state.RevertAt == nil {
+		t.Fatalf("got wrong levels: %+v", state)
+	}
+
+	// Check levels reverted
+	time.Sleep(200 * time.Millisecond)
+	if l.MinLevel() != LevelNone || l.sink("mem").minLevel.Level() != LevelNone {
+		t.Fatalf("levels not reverted: %v, %v", l.MinLevel(),
+			l.sink("mem").minLevel.Level())
+	}
+}
+
+func TestLevelHandlerSameNames(t *testing.T) {
+
+	// Sinks with the same names get unique names
+	l := New(Config{DoesNotShowInitMessage: true,
+		Sinks: []Sink{&memSink{}, &memSink{}, &memSink{}}})
+	defer l.Close()
+	h := NewLevelHandler(l)
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodPut, "/",
+		strings.NewReader(`{"sinks":{"mem-2":"ERROR"}}`)))
+	var state levelsState
+	json.NewDecoder(w.Body).Decode(&state)
+	if len(state.Sinks) != 3 || state.Sinks["mem"] != "NONE" ||
+		state.Sinks["mem-2"] != "ERROR" || state.Sinks["mem-3"] != "NONE" {
+		t.Fatalf("got wrong levels: %+v", state.Sinks)
+	}
+}
@@ -0,0 +1,53 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"sync/atomic"
+	"time"
+)
+
+// crockford is the Crockford's base32 alphabet used in ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// idSeed is a random process seed and idSeq is a sequence number of the IDs,
+// together they make the IDs created in the same millisecond unique.
+var (
+	idSeed = func() (seed uint64) {
+		var b [8]byte
+		rand.Read(b[:])
+		return binary.BigEndian.Uint64(b[:])
+	}()
+	idSeq atomic.Uint64
+)
+
+// newID returns a new log entry ID in ULID format: 26 characters of
+// Crockford's base32 with 48 bits of milliseconds timestamp and 80 bits of
+// random process seed and sequence number. The IDs are sortable by time and
+// unique across processes, so they may be used as Elasticsearch document IDs.
+func newID(t time.Time) string {
+
+	// Make 128 bits ID: 48 bits timestamp and 80 bits entropy
+	var b [16]byte
+	ms := uint64(t.UnixMilli())
+	binary.BigEndian.PutUint64(b[0:8], ms<<16)
+	binary.BigEndian.PutUint64(b[8:16], idSeed+idSeq.Add(1))
+	b[6] = byte(idSeed >> 56)
+	b[7] = byte(idSeed >> 48)
+
+	// Encode 128 bits to 26 base32 characters, the first character has 3
+	// bits only
+	var id [26]byte
+	hi := binary.BigEndian.Uint64(b[0:8])
+	lo := binary.BigEndian.Uint64(b[8:16])
+	for i := 25; i >= 0; i-- {
+		id[i] = crockford[lo&31]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(id[:])
+}
@@ -0,0 +1,109 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"slices"
+	"strings"
+	"sync/atomic"
+)
+
+// levels is a list of known log levels ordered from the lowest to the highest.
+var levels = []LogLevel{
+	LevelTrace, LevelDebug, LevelInfo, LevelWarn, LevelError, LevelPanic, LevelFatal,
+}
+
+// rank returns the log level order number. The known log levels have rank
+// from 1 (the lowest) to len(levels). LevelNone and unknown log levels, f.e.
+// parsed from the standard log messages, have the rank of LevelInfo.
+func (level LogLevel) rank() int32 {
+	for i, l := range levels {
+		if l == level {
+			return int32(i + 1)
+		}
+	}
+	return LevelInfo.rank()
+}
+
+// levelVar is a minimum log level which may be changed concurrently.
+// The zero levelVar is LevelNone which means all log levels are enabled.
+type levelVar struct{ rank atomic.Int32 }
+
+// Level returns the minimum log level.
+func (v *levelVar) Level() LogLevel {
+	rank := v.rank.Load()
+	if rank == 0 {
+		return LevelNone
+	}
+	return levels[rank-1]
+}
+
+// Set sets the minimum log level. LevelNone enables all log levels.
+func (v *levelVar) Set(level LogLevel) {
+	if level == LevelNone {
+		v.rank.Store(0)
+		return
+	}
+	v.rank.Store(level.rank())
+}
+
+// enabled reports whether log entries with the given level pass the minimum
+// log level.
+func (v *levelVar) enabled(level LogLevel) bool {
+	return level.rank() >= v.rank.Load()
+}
+
+// step changes the minimum log level by n levels up (n > 0, less verbose) or
+// down (n < 0, more verbose) and returns the new level. LevelNone is treated
+// as the lowest log level. The level stays within known log levels.
+func (v *levelVar) step(n int) LogLevel {
+	for {
+		old := v.rank.Load()
+		rank := max(old, 1) + int32(n)
+		rank = min(max(rank, 1), int32(len(levels)))
+		if v.rank.CompareAndSwap(old, rank) {
+			return levels[rank-1]
+		}
+	}
+}
+
+// levelValue is a log level which may be changed concurrently. Unlike
+// levelVar it may hold any LogLevel value.
+type levelValue struct{ v atomic.Pointer[LogLevel] }
+
+// Level returns the log level.
+func (v *levelValue) Level() LogLevel {
+	if level := v.v.Load(); level != nil {
+		return *level
+	}
+	return LevelNone
+}
+
+// Set sets the log level.
+func (v *levelValue) Set(level LogLevel) { v.v.Store(&level) }
+
+// levelName returns the log level name, LevelNone is returned as "NONE".
+func levelName(level LogLevel) string {
+	if level == LevelNone {
+		return "NONE"
+	}
+	return string(level)
+}
+
+// parseLevel returns the log level by its case insensitive name. The "NONE"
+// name returns LevelNone.
+func parseLevel(name string) LogLevel {
+	name = strings.ToUpper(name)
+	if name == "NONE" {
+		return LevelNone
+	}
+	return LogLevel(name)
+}
+
+// validLevel reports whether the name is a known log level name or "NONE".
+func validLevel(name string) bool {
+	level := parseLevel(name)
+	return level == LevelNone || slices.Contains(levels, level)
+}
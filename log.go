@@ -14,20 +14,29 @@ package log
 import (
 	"io"
 	"log"
-	"os"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
 	"strings"
+	"time"
 )
 
 // Log levels
 const (
+	LevelTrace LogLevel = "TRACE"
 	LevelDebug LogLevel = "DEBUG"
 	LevelInfo  LogLevel = "INFO"
 	LevelWarn  LogLevel = "WARN"
 	LevelError LogLevel = "ERROR"
+	LevelPanic LogLevel = "PANIC"
+	LevelFatal LogLevel = "FATAL"
 	LevelNone  LogLevel = ""
 )
 
-// Default log level uset in standart log calls, f.e. log.Println
+// Default log level uset in standart log calls, f.e. log.Println, of the
+// Loggers created after it was changed. Use SetDefaultLevel to change default
+// log level of the default Logger.
 var LevelDefault = LevelDebug
 
 // Config is a struct that holds configuration information for the logger.
@@ -61,135 +70,185 @@ type Config struct {
 
 	// FilterLevel is a list of log levels to filter out.
 	FilterLevels []LogLevel
+
+	// MinLevel is a minimum log level. Log entries below this level are not
+	// sent to any sink. If not set, all log levels are sent.
+	MinLevel LogLevel
+
+	// StdoutMinLevel is a minimum log level of the stdout sink. If not set,
+	// all log levels are sent to stdout.
+	StdoutMinLevel LogLevel
+
+	// StdoutFormatter is a formatter of the stdout sink, f.e.
+	// ConsoleFormatter{} or JSONFormatter{}. If nil, the TextFormatter is
+	// used.
+	StdoutFormatter Formatter
+
+	// StdoutQueueSize is a queue size of the stdout sink. If positive, the
+	// stdout sink is queued like other sinks, so a blocked stdout pipe does
+	// not block the log calls until its queue is full, but the queued log
+	// entries are written only if the Logger is flushed or closed before the
+	// program exits.
+	// If not set, log entries are written to stdout synchronously from the
+	// log calls.
+	StdoutQueueSize int
+
+	// AddCaller is a boolean that indicates whether to add caller file, line
+	// and function to log entries.
+	AddCaller bool
+
+	// CallerSkip is a number of additional caller frames to skip. It is used
+	// when log functions are called from the application wrapper functions.
+	CallerSkip int
+
+	// FlushTimeout is a maximum time to flush sinks before exit or panic on
+	// the fatal and panic log levels. If not set, Default is 5 seconds.
+	FlushTimeout time.Duration
+
+	// StackTrace is a boolean that indicates whether to add the goroutine
+	// stack trace to the ERROR and above log entries.
+	StackTrace bool
+
+	// SignalLevelControl enables the minimum log level change on Unix
+	// signals: SIGUSR1 steps verbosity up one level and SIGUSR2 steps it
+	// down. It is ignored on non-Unix systems.
+	SignalLevelControl bool
+
+	// DropReportInterval is an interval of the "dropped N entries" warnings
+	// sent when sinks drop log entries because of their queue overflow
+	// policy. The report is started only if some sink has the overflow policy
+	// other than OverflowBlock. If not set, Default is 10 seconds. If
+	// negative, the warnings are not sent.
+	DropReportInterval time.Duration
+
+	// SlogHandler is an additional slog handler which receives all log
+	// entries. If nil, the slog handler is not used. Do not use the SlogHandler
+	// of the same Logger here, it will loop.
+	SlogHandler slog.Handler
+
+	// Sinks is a list of additional log entries destinations.
+	Sinks []Sink
 }
 
 // Fields is a map of string to any
 type Fields map[string]any
 
-// Log level by appType
-var appType string = "TEST"
-
-// The stdoutLogger is a logger that writes to stdout
-var stdoutLogger = log.New(os.Stdout, "", 0)
-
-// loggers is a struct that holds information about how to send log entries to
-// loggers.
-var loggers = newLoggers()
+// stdlogPrefix matches the standard log prefix: date, time and Go file:line,
+// all parts are optional.
+var stdlogPrefix = regexp.MustCompile(
+	`^(\d{4}/\d{2}/\d{2} )?(\d{2}:\d{2}:\d{2}(?:\.\d+)? )?(?:([^\s:]+\.go):(\d+): )?`)
 
 // customWriter is io.Writer interface
-type customWriter struct{}
+type customWriter struct{ l *Logger }
 
 // Write implements io.Writer interface and is used to write log entries to stdout.
 // It removes timestamp from the log entry and then sends the log entry to the loggers.
 // The log level is determined by the text inside `[...]` if `[` exists at the beginning of the log entry.
-// If the log level is not specified, it defaults to LevelDebug.
+// If the log level is not specified, it defaults to the Logger default level.
 // The log entry is then sent to the loggers, which writes it to stdout and/or to Elasticsearch.
 // The Write function returns the number of bytes written and a nil error.
 func (cw *customWriter) Write(p []byte) (n int, err error) {
 
-	// If LevelDefault is LevelNone, message is ignored
-	if LevelDefault == LevelNone {
+	// If default level is LevelNone, message is ignored
+	levelDefault := cw.l.levelDefault.Level()
+	if levelDefault == LevelNone {
 		return len(p), nil
 	}
 
-	// Remove timestamp from p, p is like this '2025/10/19 10:28:50.567024 ...'
-	p = p[strings.Index(string(p), " ")+1:]
-	p = p[strings.Index(string(p), " ")+1:]
+	origLen := len(p)
+
+	// Remove standard log prefix from p, p is like this
+	// '2025/10/19 10:28:50.567024 main.go:12: ...', all prefix parts are
+	// optional and depend on the standard logger flags. The time without
+	// date and file:line is not removed, f.e. in the "12:30:00 job started"
+	// message logged without flags
+	var caller *Caller
+	if m := stdlogPrefix.FindSubmatch(p); m != nil {
+		if len(m[1]) == 0 && len(m[3]) == 0 {
+			m[0] = m[0][:len(m[0])-len(m[2])]
+		}
+		if len(m[3]) > 0 {
+			line, _ := strconv.Atoi(string(m[4]))
+			caller = &Caller{File: string(m[3]), Line: line}
+		}
+		p = p[len(m[0]):]
+	}
 
 	// Get text inside `[...]` if `[`` exists at beginning of p
-	level := string(LevelDefault)
+	level := string(levelDefault)
 	if strings.HasPrefix(string(p), "[") && strings.Contains(string(p), "]") {
 		level = string(p[strings.Index(string(p), "[")+1 : strings.Index(string(p), "]")])
 		p = p[strings.Index(string(p), "]")+1:]
-	}
-
-	loggers.send(entry(LogLevel(level), strings.TrimSpace(string(p))))
-
-	return len(p), nil
-}
-
-// Init sets up the loggers and sets the output for the default application
-// logger and for some additional loggers.
-// It is called once when the application starts.
-// apptype is the application type, which is used to prefix log messages.
-// useStdout is a boolean that indicates whether to write log messages to stdout.
-// esConfig is a pointer to an EsConfig struct, which holds information about
-// how to send log entries to Elasticsearch.
-// If esConfig is not nil, the loggers are set up to write log entries to Elasticsearch.
-// The Elasticsearch logger config is set to the provided esConfig.
-// The Elasticsearch logger config is set to the provided esConfig.
-// If the MaxOverflowBuffer is 0, it is set to 2x the entryChannel size, or a
-// fixed number if entryChannel size is 0.
-// If the MaxRetryBuffer is 0, it is set to 100 batches.
-// The Elasticsearch logger config is set to the provided esConfig.
-// The Elasticsearch logger config is set to the provided esConfig.
-// The loggers are then started and the output for the default application logger
-// and for some additional loggers is set to a customWriter, which writes log
-// entries to stdout and/or to Elasticsearch.
-// Finally, a message is printed to indicate that the loggers have been initialized.
-func Init(config Config) {
-
-	// Set application type
-	appType = config.AppType
-
-	// Set useStdout
-	loggers.useStdoutLogger = config.UseStdout
-
-	// Set filter level
-	loggers.filterLevels = config.FilterLevels
-
-	// Set output for default application logger
-	w := &customWriter{}
-	log.SetOutput(w)
 
-	// Add custom logers
-	for _, customLogger := range config.CustomLogers {
-		customLogger.SetOutput(w)
+		// Use known log level name in upper case
+		if known := LogLevel(strings.ToUpper(level)); slices.Contains(levels, known) {
+			level = string(known)
+		}
 	}
 
-	// Set elasticsearch logger config and start elasticsearch logger handler
-	if config.EsConfig != nil {
-		loggers.es.init(config.AppShort, config.EsConfig)
-		loggers.useEsLogger = true
+	// Skip log levels which are not sent to any sink
+	if !cw.l.enabled(LogLevel(level)) {
+		return origLen, nil
 	}
 
-	// Set file logger config and start file logger handler
-	if config.FileConfig != nil {
-		loggers.file.init(config.AppShort, config.FileConfig)
-		loggers.useFailLogger = true
+	// Get caller from the stack or from the standard log prefix, and the
+	// stack trace of the ERROR and above log levels
+	entry := cw.l.entry(LogLevel(level), strings.TrimSpace(string(p)))
+	entry.Caller = caller
+	cw.l.stdlogSource(entry)
+	cw.l.send(entry)
+
+	// Flush sinks on the panic and fatal log levels, the standard log
+	// functions, f.e. log.Fatalln, exit after Write returns
+	if entry.Level == LevelPanic || entry.Level == LevelFatal {
+		cw.l.flushTimeout()
 	}
 
-	// Wait for loggers to start
-	loggers.wgStart.Wait()
+	return origLen, nil
+}
 
-	// Print message when loggers are initialized
-	if !config.DoesNotShowInitMessage {
-		log.Println("logger initialized")
+// Init creates a new Logger with the given config, makes it the default
+// Logger and sets the output for the default application logger to it.
+// It is called once when the application starts.
+//
+// See New for description of how the config is applied. The previous default
+// Logger is closed by Init, so its buffered log entries are sent and its
+// goroutines are stopped. Use SetDefault to replace the default Logger
+// without closing it.
+func Init(config Config) {
+	prev := Default()
+	SetDefault(New(config))
+	if prev != nil {
+		prev.Close()
 	}
 }
 
-// CLose closes the Elasticsearch logger and the file logger.
-// It is called once when the application exits.
+// CLose closes the Elasticsearch logger and the file logger of the default
+// Logger. It is called once when the application exits.
 // It stops the Elasticsearch logger and the file logger from writing log
 // entries to Elasticsearch and/or to disk.
-func CLose() {
-	if loggers.useEsLogger {
-		loggers.es.close()
-	}
+func CLose() { Default().Close() }
 
-	if loggers.useFailLogger {
-		loggers.file.close()
-	}
+// SetMinLevel sets the minimum log level for the default Logger.
+//
+// The logger will write log messages with the specified log level and above
+// (i.e., if the log level is set to WARN, the logger will write log messages
+// with the log levels WARN and ERROR). If the minimum log level is set to NONE,
+// all log messages are written.
+//
+// The minimum log level of each sink may be set with Logger.SetSinkMinLevel.
+func SetMinLevel(level LogLevel) { Default().SetMinLevel(level) }
 
-	loggers.wgClose.Wait()
-}
+// With returns a child of the default Logger which adds the given fields to
+// every log entry. See Logger.With.
+func With(fields Fields) *Logger { return Default().With(fields) }
 
 // SetOutput sets the output destination for the standard logger.
 func SetOutput(w io.Writer) {
 	log.SetOutput(w)
 }
 
-// SetDefaultLevel sets the default log level for the logger.
+// SetDefaultLevel sets the default log level for the default Logger.
 //
 // The default log level is DEBUG. The default log level can be changed using
 // this function.
@@ -199,21 +258,18 @@ func SetOutput(w io.Writer) {
 // standart log calls.
 //
 // It takes a logLevel value as its argument, which can be any of the following:
-// DEBUG, INFO, WARN, ERROR, or NONE.
+// TRACE, DEBUG, INFO, WARN, ERROR, PANIC, FATAL or NONE.
 //
-// The logger will write log messages with the specified log level and above
-// (i.e., if the log level is set to WARN, the logger will write log messages
-// with the log levels WARN and ERROR).
-func SetDefaultLevel(level LogLevel) {
-	LevelDefault = level
-}
+// The default log level does not filter log messages, use SetMinLevel to
+// write log messages with the specified log level and above only.
+func SetDefaultLevel(level LogLevel) { Default().SetDefaultLevel(level) }
 
 // Sentry is a convenience function for creating log entries at the given log level.
 // It takes a message, and a variable argument list of maps, allowing the caller to pass in any number
 // of fields to be included in the log entry. The first map in the list is used as the fields for the log entry.
 func Sentry(level LogLevel, v ...any) string {
 	// Return a log entry with the given message and fields at the given log level.
-	return entry(level, v...).String()
+	return Default().sentry(1, level, v...)
 }
 
 // Sentryf is a convenience function for creating log entries at the given log level.
@@ -227,7 +283,7 @@ func Sentry(level LogLevel, v ...any) string {
 // in via the variable argument list.
 func Sentryf(level LogLevel, format string, v ...any) string {
 	// Return a log entry with the given format string and values at the given log level.
-	return entryf(level, format, v...).String()
+	return Default().sentryf(1, level, format, v...)
 }
 
 // Sdebug is a convenience function for creating log entries at the debug log level.
@@ -236,7 +292,7 @@ func Sentryf(level LogLevel, format string, v ...any) string {
 // the fields for the log entry.
 func Sdebug(v ...any) string {
 	// Return a log entry with the given message and fields at the debug log level.
-	return entry(LevelDebug, v...).String()
+	return Default().sentry(1, LevelDebug, v...)
 }
 
 // Sdebugf is a convenience function for creating log entries at the debug log level.
@@ -245,7 +301,7 @@ func Sdebug(v ...any) string {
 // list is expected to be a map[string]any, which is used as the fields for the log
 // entry.
 func Sdebugf(format string, v ...any) string {
-	return entryf(LevelDebug, format, v...).String()
+	return Default().sentryf(1, LevelDebug, format, v...)
 }
 
 // Sinfo is a convenience function for creating log entries at the info log level.
@@ -254,7 +310,7 @@ func Sdebugf(format string, v ...any) string {
 // the fields for the log entry.
 func Sinfo(message string, v ...any) string {
 	// Return a log entry with the given message and fields at the info log level.
-	return entry(LevelInfo, v...).String()
+	return Default().sentry(1, LevelInfo, v...)
 }
 
 // Sinfof is a convenience function for creating log entries at the info log level.
@@ -268,7 +324,7 @@ func Sinfo(message string, v ...any) string {
 // in via the variable argument list.
 func Sinfof(format string, v ...any) string {
 	// Return a log entry with the given format string and values at the info log level.
-	return entryf(LevelInfo, format, v...).String()
+	return Default().sentryf(1, LevelInfo, format, v...)
 }
 
 // Swarn is a convenience function for creating log entries at the warn log level.
@@ -277,7 +333,7 @@ func Sinfof(format string, v ...any) string {
 // the fields for the log entry.
 func Swarn(message string, v ...any) string {
 	// Return a log entry with the given message and fields at the warn log level.
-	return entry(LevelWarn, v...).String()
+	return Default().sentry(1, LevelWarn, v...)
 }
 
 // Swarnf is a convenience function for creating log entries at the warn log level.
@@ -291,7 +347,7 @@ func Swarn(message string, v ...any) string {
 // in via the variable argument list.
 func Swarnf(format string, v ...any) string {
 	// Return a log entry with the given format string and values at the warn log level.
-	return entryf(LevelWarn, format, v...).String()
+	return Default().sentryf(1, LevelWarn, format, v...)
 }
 
 // Serror is a convenience function for creating log entries at the error log level.
@@ -302,7 +358,7 @@ func Swarnf(format string, v ...any) string {
 // The function returns a JSON representation of the log entry as a string.
 func Serror(message string, v ...any) string {
 	// Return a log entry with the given message and fields at the error log level.
-	return entry(LevelError, v...).String()
+	return Default().sentry(1, LevelError, v...)
 }
 
 // Serrorf is a convenience function for creating log entries at the error log level.
@@ -316,7 +372,7 @@ func Serror(message string, v ...any) string {
 // in via the variable argument list.
 func Serrorf(format string, v ...any) string {
 	// Return a log entry with the given format string and values at the error log level.
-	return entryf(LevelError, format, v...).String()
+	return Default().sentryf(1, LevelError, format, v...)
 }
 
 // PrintLevel is a convenience function for creating log entries at the given log level.
@@ -324,7 +380,7 @@ func Serrorf(format string, v ...any) string {
 // of fields to be included in the log entry. The first map in the list is used as
 // the fields for the log entry.
 func PrintLevel(level LogLevel, v ...any) {
-	loggers.send(entry(level, v...)) // Send to Stdout and Elasticsearch
+	Default().printLevel(1, level, v...) // Send to Stdout and Elasticsearch
 }
 
 // PrintLevelf is a convenience function for creating log entries at the given log level.
@@ -337,14 +393,17 @@ func PrintLevel(level LogLevel, v ...any) {
 // list. The resulting log entry will contain the formatted message and the fields passed
 // in via the variable argument list.
 func PrintLevelf(level LogLevel, format string, v ...any) {
-	loggers.send(entryf(level, format, v...)) // Send to Stdout and Elasticsearch
+	Default().printLevelf(1, level, format, v...) // Send to Stdout and Elasticsearch
 }
 
 // Println is a convenience function for creating log entries at the debug log level.
 // It takes a variable argument list of values, allowing the caller to pass in any number
 // of values to be included in the log entry. The first map in the list is used as
 // the fields for the log entry.
-func Println(v ...any) { PrintLevel(LevelDefault, v...) }
+func Println(v ...any) {
+	l := Default()
+	l.printLevel(1, l.levelDefault.Level(), v...)
+}
 
 // Printf is a convenience function for creating log entries at the debug log level.
 // It takes a format string and a variable argument list of values, allowing the caller
@@ -355,31 +414,34 @@ func Println(v ...any) { PrintLevel(LevelDefault, v...) }
 // The format string is used to format the values passed in via the variable argument
 // list. The resulting log entry will contain the formatted message and the fields passed
 // in via the variable argument list.
-func Printf(format string, v ...any) { PrintLevelf(LevelDefault, format, v...) }
+func Printf(format string, v ...any) {
+	l := Default()
+	l.printLevelf(1, l.levelDefault.Level(), format, v...)
+}
 
-// Fatalln is a convenience function for creating log entries at the error log level
+// Fatalln is a convenience function for creating log entries at the fatal log level
 // and then exiting the program with a non-zero exit code.
 //
 // It takes a variable argument list of values, allowing the caller to pass in any number
 // of values to be included in the log entry. The first map in the list is used as
 // the fields for the log entry.
 //
-// The function calls Fatal with the given values and then exits the program with a non-zero
-// exit code.
-func Fatalln(v ...any) { Fatal(v...) }
+// The function logs the given values like Fatal, flushes all sinks and then exits the
+// program with a non-zero exit code.
+func Fatalln(v ...any) { l := Default(); l.fatal(1, l.entry(LevelFatal, v...)) }
 
-// Fatal is a convenience function for creating log entries at the error log level
+// Fatal is a convenience function for creating log entries at the fatal log level
 // and then exiting the program with a non-zero exit code.
 //
 // It takes a variable argument list of values, allowing the caller to pass in any number
 // of values to be included in the log entry. The first map in the list is used as
 // the fields for the log entry.
 //
-// The function calls Error with the given values and then exits the program with a non-zero
-// exit code.
-func Fatal(v ...any) { Error(v...); os.Exit(1) }
+// The function logs the given values at the fatal log level, flushes all sinks with
+// Config.FlushTimeout deadline and then exits the program with a non-zero exit code.
+func Fatal(v ...any) { l := Default(); l.fatal(1, l.entry(LevelFatal, v...)) }
 
-// Fatalf is a convenience function for creating log entries at the error log level
+// Fatalf is a convenience function for creating log entries at the fatal log level
 // and then exiting the program with a non-zero exit code.
 //
 // It takes a format string and a variable argument list of values, allowing the caller
@@ -387,22 +449,59 @@ func Fatal(v ...any) { Error(v...); os.Exit(1) }
 // list is expected to be a map[string]any, which is used as the fields for the log
 // entry.
 //
-// The function calls Errorf with the given format string and values and then exits the
-// program with a non-zero exit code.
-func Fatalf(format string, v ...any) { Errorf(format, v...); os.Exit(1) }
+// The function logs the given format string and values at the fatal log level, flushes
+// all sinks with Config.FlushTimeout deadline and then exits the program with a non-zero
+// exit code.
+func Fatalf(format string, v ...any) {
+	l := Default()
+	l.fatal(1, l.entryf(LevelFatal, format, v...))
+}
+
+// Panic is a convenience function for creating log entries at the panic log level,
+// flushing all sinks and then panicking with the log message.
+//
+// It takes a variable argument list of values, allowing the caller to pass in any number
+// of values to be included in the log entry. The first map in the list is used as
+// the fields for the log entry.
+func Panic(v ...any) { l := Default(); l.panic(1, l.entry(LevelPanic, v...)) }
+
+// Panicf is a convenience function for creating log entries at the panic log level,
+// flushing all sinks and then panicking with the log message.
+//
+// It takes a format string and a variable argument list of values, allowing the caller
+// to pass in any number of values to be included in the log entry. The last value in the
+// list is expected to be a map[string]any, which is used as the fields for the log
+// entry.
+func Panicf(format string, v ...any) {
+	l := Default()
+	l.panic(1, l.entryf(LevelPanic, format, v...))
+}
+
+// Trace is a convenience function for creating log entries at the trace log level.
+// It takes a variable argument list of maps, allowing the caller to pass in any number
+// of fields to be included in the log entry. The first map in the list is used as
+// the fields for the log entry.
+func Trace(v ...any) { Default().printLevel(1, LevelTrace, v...) }
+
+// Tracef is a convenience function for creating log entries at the trace log level.
+// It takes a format string and a variable argument list of values, allowing the caller
+// to pass in any number of values to be included in the log entry. The last value in the
+// list is expected to be a map[string]any, which is used as the fields for the log
+// entry.
+func Tracef(format string, v ...any) { Default().printLevelf(1, LevelTrace, format, v...) }
 
 // Debug is a convenience function for creating log entries at the debug log level.
 // It takes a variable argument list of maps, allowing the caller to pass in any number
 // of fields to be included in the log entry. The first map in the list is used as
 // the fields for the log entry.
-func Debug(v ...any) { PrintLevel(LevelDebug, v...) }
+func Debug(v ...any) { Default().printLevel(1, LevelDebug, v...) }
 
 // Debugf is a convenience function for creating log entries at the debug log level.
 // It takes a format string and a variable argument list of values, allowing the caller
 // to pass in any number of values to be included in the log entry. The last value in the
 // list is expected to be a map[string]any, which is used as the fields for the log
 // entry.
-func Debugf(format string, v ...any) { PrintLevelf(LevelDebug, format, v...) }
+func Debugf(format string, v ...any) { Default().printLevelf(1, LevelDebug, format, v...) }
 
 // Example usage:
 // Debugf("Something happened with %v and %v", "foo", "bar", map[string]any{"foo": "bar"})
@@ -411,37 +510,37 @@ func Debugf(format string, v ...any) { PrintLevelf(LevelDebug, format, v...) }
 // It takes a variable argument list of maps, allowing the caller to pass in any number
 // of fields to be included in the log entry. The first map in the list is used as
 // the fields for the log entry.
-func Info(v ...any) { PrintLevel(LevelInfo, v...) }
+func Info(v ...any) { Default().printLevel(1, LevelInfo, v...) }
 
 // Infof is a convenience function for creating log entries at the info log level.
 // It takes a format string and a variable argument list of values, allowing the caller
 // to pass in any number of values to be included in the log entry. The last value in the
 // list is expected to be a map[string]any, which is used as the fields for the log
 // entry.
-func Infof(format string, v ...any) { PrintLevelf(LevelInfo, format, v...) }
+func Infof(format string, v ...any) { Default().printLevelf(1, LevelInfo, format, v...) }
 
 // Warn is a convenience function for creating log entries at the warn log level.
 // It takes a variable argument list of maps, allowing the caller to pass in any number
 // of fields to be included in the log entry. The first map in the list is used as
 // the fields for the log entry.
-func Warn(v ...any) { PrintLevel(LevelWarn, v...) }
+func Warn(v ...any) { Default().printLevel(1, LevelWarn, v...) }
 
 // Warnf is a convenience function for creating log entries at the warn log level.
 // It takes a format string and a variable argument list of values, allowing the caller
 // to pass in any number of values to be included in the log entry. The last value in the
 // list is expected to be a map[string]any, which is used as the fields for the log
 // entry.
-func Warnf(format string, v ...any) { PrintLevelf(LevelWarn, format, v...) }
+func Warnf(format string, v ...any) { Default().printLevelf(1, LevelWarn, format, v...) }
 
 // Error is a convenience function for creating log entries at the error log level.
 // It takes a variable argument list of maps, allowing the caller to pass in any number
 // of fields to be included in the log entry. The first map in the list is used as
 // the fields for the log entry.
-func Error(v ...any) { PrintLevel(LevelError, v...) }
+func Error(v ...any) { Default().printLevel(1, LevelError, v...) }
 
 // Errorf is a convenience function for creating log entries at the error log level.
 // It takes a format string and a variable argument list of values, allowing the caller
 // to pass in any number of values to be included in the log entry. The last value in the
 // list is expected to be a map[string]any, which is used as the fields for the log
 // entry.
-func Errorf(format string, v ...any) { PrintLevelf(LevelError, format, v...) }
+func Errorf(format string, v ...any) { Default().printLevelf(1, LevelError, format, v...) }
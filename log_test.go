@@ -7,12 +7,13 @@ package log
 import (
 	"log"
 	"testing"
+	"time"
 )
 
 func TestLog(t *testing.T) {
 
 	// Test entry
-	t.Log(entry(LevelDebug, "entry() test", map[string]any{"key": "value"}))
+	t.Log(Default().entry(LevelDebug, "entry() test", map[string]any{"key": "value"}))
 
 	// Test SDebug
 	t.Log(Sdebug("Sdebug() test", map[string]any{"key": "value"}))
@@ -48,3 +49,78 @@ func TestLog(t *testing.T) {
 	// Some debug message with default log level set to NONE
 	Debug("some debug message", map[string]any{"key": "value"})
 }
+
+func TestInitTwice(t *testing.T) {
+
+	// First Init holds the Elasticsearch batch
+	s := newEsServer(t)
+	Init(Config{DoesNotShowInitMessage: true, EsConfig: &EsConfig{
+		ES_URL:      s.URL,
+		FailoverDir: t.TempDir(),
+		TimeToHold:  time.Hour,
+	}})
+	first := Default()
+	Info("buffered message")
+
+	// Second Init closes the first Logger and sends its batch
+	Init(Config{DoesNotShowInitMessage: true, UseStdout: true})
+	if docs := s.documents(); len(docs) != 1 || docs[0]["message"] != "buffered message" {
+		t.Fatalf("got documents %v", docs)
+	}
+	if Default() == first || !first.closed {
+		t.Fatal("first Logger is not replaced and closed")
+	}
+}
+
+func TestNew(t *testing.T) {
+
+	// Create two loggers with different configurations
+	l1 := New(Config{AppShort: "log-test-1", AppType: "DEV", UseStdout: true,
+		FileConfig: &FileConfig{Folder: t.TempDir()},
+	})
+	defer l1.Close()
+	l2 := New(Config{AppShort: "log-test-2", AppType: "PROD", UseStdout: true})
+	defer l2.Close()
+
+	// Check that loggers does not share state
+	if l1.appType == l2.appType {
+		t.Fatal("loggers share application type")
+	}
+	l2.SetDefaultLevel(LevelNone)
+	if l1.levelDefault.Level() == LevelNone {
+		t.Fatal("loggers share default level")
+	}
+
+	l1.Info("Info() test from logger 1", Fields{"key": "value"})
+	l2.Info("Info() test from logger 2", Fields{"key": "value"})
+}
+
+func TestWith(t *testing.T) {
+
+	l := New(Config{AppShort: "log-test", DoesNotShowInitMessage: true})
+	defer l.Close()
+
+	// Create nested loggers with bound fields
+	child := l.With(Fields{"request_id": "1", "user": "alice"})
+	grandchild := child.With(Fields{"user": "bob"})
+
+	// Check bound fields merged and overridden
+	e := grandchild.entry(LevelInfo, "test", Fields{"request_id": "2", "key": "value"})
+	want := Fields{"request_id": "2", "user": "bob", "key": "value"}
+	if len(e.Fields) != len(want) {
+		t.Fatalf("got fields %v, want %v", e.Fields, want)
+	}
+	for k, v := range want {
+		if e.Fields[k] != v {
+			t.Fatalf("got fields %v, want %v", e.Fields, want)
+		}
+	}
+
+	// Check parent logger fields are not changed
+	if e := child.entry(LevelInfo, "test"); e.Fields["user"] != "alice" {
+		t.Fatalf("got parent fields %v", e.Fields)
+	}
+	if e := l.entry(LevelInfo, "test"); e.Fields != nil {
+		t.Fatalf("got root fields %v", e.Fields)
+	}
+}
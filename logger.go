@@ -0,0 +1,434 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"sync/atomic"
+	"time"
+)
+
+// Logger is a logger instance. It owns its own loggers (stdout, file and
+// Elasticsearch), log levels and wait groups, so several Loggers with
+// different configurations may be used in one application.
+//
+// The package level functions, f.e. Info or Debugf, use the default Logger
+// which may be replaced with SetDefault.
+type Logger struct {
+	*loggersType
+
+	// bound is a fields bound to this Logger with the With method
+	bound *boundFields
+}
+
+// boundFields is a list of fields bound to Logger. Every With call adds new
+// element to the list which points to the parent Logger fields, so nested
+// Loggers do not copy parent fields.
+type boundFields struct {
+	parent *boundFields
+	fields Fields
+}
+
+// std is the default Logger used by the package level functions.
+var std atomic.Pointer[Logger]
+
+func init() {
+	std.Store(New(Config{
+		AppType:                "TEST",
+		UseStdout:              true,
+		DoesNotShowInitMessage: true,
+	}))
+}
+
+// New creates a new Logger with the given configuration and starts its
+// sinks.
+//
+// The stdout sink is added if config.UseStdout is true, the Elasticsearch
+// sink is added if config.EsConfig is not nil, the file sink is added if
+// config.FileConfig is not nil and the slog sink is added if
+// config.SlogHandler is not nil. Then config.Sinks are added. The custom
+// loggers from config.CustomLogers are redirected to the new Logger.
+//
+// Use Close to stop the Logger when it is not needed anymore.
+func New(config Config) *Logger {
+
+	// Create logger
+	l := &Logger{loggersType: newLoggers()}
+
+	// Set application short name and type
+	l.appShort = config.AppShort
+	l.appType = config.AppType
+
+	// Set caller parameters
+	l.addCaller = config.AddCaller
+	l.callerSkip = config.CallerSkip
+	l.stackTrace = config.StackTrace
+
+	// Set flush timeout
+	l.flushTimeoutDuration = config.FlushTimeout
+	if l.flushTimeoutDuration == 0 {
+		l.flushTimeoutDuration = 5 * time.Second
+	}
+
+	// Set filter level and minimum level
+	l.filterLevels = config.FilterLevels
+	l.minLevel.Set(config.MinLevel)
+
+	// Add custom logers
+	for _, customLogger := range config.CustomLogers {
+		customLogger.SetOutput(l.Writer())
+	}
+
+	// Add stdout sink
+	if config.UseStdout {
+		formatter := config.StdoutFormatter
+		if formatter == nil {
+			formatter = TextFormatter{}
+		}
+		l.addSink(&stdoutSink{l.stdout, config.StdoutMinLevel, formatter,
+			config.StdoutQueueSize})
+	}
+
+	// Add elasticsearch sink
+	if config.EsConfig != nil {
+		l.addSink(newEs(l, config.EsConfig))
+	}
+
+	// Add file sink
+	if config.FileConfig != nil {
+		l.addSink(newFile(l, config.FileConfig))
+	}
+
+	// Add slog handler sink
+	if config.SlogHandler != nil {
+		l.addSink(&slogSink{config.SlogHandler})
+	}
+
+	// Add custom sinks
+	for _, sink := range config.Sinks {
+		l.addSink(sink)
+	}
+
+	// Start signal level control
+	if config.SignalLevelControl {
+		l.startSignals()
+	}
+
+	// Start dropped log entries report if some sink may drop log entries
+	interval := config.DropReportInterval
+	if interval == 0 {
+		interval = defaultDropReportInterval
+	}
+	if interval > 0 && slices.ContainsFunc(l.sinks, (*sinkQueue).mayDrop) {
+		l.startDropReport(interval)
+	}
+
+	// Print message when loggers are initialized
+	if !config.DoesNotShowInitMessage {
+		l.Println("logger initialized")
+	}
+
+	return l
+}
+
+// Default returns the default Logger used by the package level functions.
+func Default() *Logger { return std.Load() }
+
+// SetDefault makes l the default Logger used by the package level functions
+// and redirects the standard log package output to it.
+func SetDefault(l *Logger) {
+	std.Store(l)
+	log.SetOutput(l.Writer())
+}
+
+// Close closes all sinks of this Logger, f.e. the Elasticsearch logger and
+// the file logger. It stops the sinks from writing log entries to
+// Elasticsearch and/or to disk and waits until they are finished. Log entries
+// sent after Close are dropped.
+//
+// Child Loggers created with With share sinks with their parent, so closing
+// any of them closes all.
+func (l *Logger) Close() { l.close() }
+
+// With returns a child Logger which adds the given fields to every log entry.
+// The child Logger uses the same loggers as its parent. Fields passed to the
+// log calls override bound fields with the same keys.
+//
+// The fields map is copied, so it may be changed after With returns.
+func (l *Logger) With(fields Fields) *Logger {
+	if len(fields) == 0 {
+		return l
+	}
+
+	// Copy fields to make them immutable
+	return &Logger{l.loggersType, &boundFields{l.bound, maps.Clone(fields)}}
+}
+
+// mergeFields returns the bound fields merged with the context fields and
+// the given fields. The given fields override the context fields and the
+// context fields override the bound fields. If there are no bound and context
+// fields, the given fields are returned as is. The ctx may be nil.
+func (l *Logger) mergeFields(ctx context.Context, fields Fields) Fields {
+	if l.bound == nil && ctx == nil {
+		return fields
+	}
+
+	// Get fields list from bound fields, context extractors and context
+	list := l.bound.list(nil)
+	if ctx != nil {
+		for _, extract := range extractors.load() {
+			list = append(list, extract(ctx))
+		}
+		list = contextFields(ctx).list(list)
+	}
+	list = append(list, fields)
+
+	// Merge fields, the last one wins
+	size := 0
+	for _, f := range list {
+		size += len(f)
+	}
+	if size == 0 {
+		return fields
+	}
+	merged := make(Fields, size)
+	for _, f := range list {
+		maps.Copy(merged, f)
+	}
+
+	return merged
+}
+
+// list appends fields from root to leaf to the list and returns it. The b
+// may be nil.
+func (b *boundFields) list(list []Fields) []Fields {
+	if b == nil {
+		return list
+	}
+	return append(b.parent.list(list), b.fields)
+}
+
+// Flush writes all queued and buffered log entries of all sinks and waits
+// until they are written or the context is done.
+func (l *Logger) Flush(ctx context.Context) error { return l.flush(ctx) }
+
+// Writer returns an io.Writer which parses standard log package output and
+// sends it to this Logger. It may be used as output of the standard
+// log.Logger, f.e. log.SetOutput(l.Writer()).
+func (l *Logger) Writer() io.Writer { return &customWriter{l} }
+
+// SetDefaultLevel sets the default log level of this Logger. The default log
+// level used in the standart log calls, f.e. log.Println, and in the Println
+// and Printf methods.
+func (l *Logger) SetDefaultLevel(level LogLevel) { l.levelDefault.Set(level) }
+
+// DefaultLevel returns the default log level of this Logger.
+func (l *Logger) DefaultLevel() LogLevel { return l.levelDefault.Level() }
+
+// SetMinLevel sets the minimum log level of this Logger. Log entries below
+// this level are not sent to any sink. LevelNone enables all log levels.
+func (l *Logger) SetMinLevel(level LogLevel) { l.minLevel.Set(level) }
+
+// MinLevel returns the minimum log level of this Logger.
+func (l *Logger) MinLevel() LogLevel { return l.minLevel.Level() }
+
+// SetSinkMinLevel sets the minimum log level of the sink with the given name,
+// f.e. "stdout", "file" or "elasticsearch". LevelNone enables all log levels
+// of the sink.
+func (l *Logger) SetSinkMinLevel(name string, level LogLevel) error {
+	sink := l.sink(name)
+	if sink == nil {
+		return fmt.Errorf("sink %q not found", name)
+	}
+	sink.minLevel.Set(level)
+	return nil
+}
+
+// stepMinLevel steps the minimum log level of this Logger by n levels up
+// (less verbose) or down (more verbose) and logs a notice about it.
+func (l *Logger) stepMinLevel(n int, reason string) {
+	level := l.minLevel.step(n)
+	l.notice(l.entryf(LevelInfo, "log level changed to %s by %s", level, reason))
+}
+
+// Enabled reports whether log entries with the given level are sent to at
+// least one sink of this Logger.
+func (l *Logger) Enabled(level LogLevel) bool { return l.enabled(level) }
+
+// Sentry creates log entry at the given log level and returns it as string.
+func (l *Logger) Sentry(level LogLevel, v ...any) string {
+	return l.sentry(1, level, v...)
+}
+
+// Sentryf creates log entry at the given log level using format string and
+// returns it as string.
+func (l *Logger) Sentryf(level LogLevel, format string, v ...any) string {
+	return l.sentryf(1, level, format, v...)
+}
+
+// PrintLevel creates log entry at the given log level and sends it to the
+// loggers. The last value in the list may be a map[string]any or Fields, which
+// is used as the fields for the log entry.
+func (l *Logger) PrintLevel(level LogLevel, v ...any) { l.printLevel(1, level, v...) }
+
+// PrintLevelf creates log entry at the given log level using format string
+// and sends it to the loggers. The last value in the list may be a
+// map[string]any or Fields, which is used as the fields for the log entry.
+func (l *Logger) PrintLevelf(level LogLevel, format string, v ...any) {
+	l.printLevelf(1, level, format, v...)
+}
+
+// Println sends log entry at the default log level.
+func (l *Logger) Println(v ...any) { l.printLevel(1, l.levelDefault.Level(), v...) }
+
+// Printf sends formatted log entry at the default log level.
+func (l *Logger) Printf(format string, v ...any) {
+	l.printLevelf(1, l.levelDefault.Level(), format, v...)
+}
+
+// Fatalln sends log entry at the fatal log level, flushes all sinks and then
+// exits the program with a non-zero exit code.
+func (l *Logger) Fatalln(v ...any) { l.fatal(1, l.entry(LevelFatal, v...)) }
+
+// Fatal sends log entry at the fatal log level, flushes all sinks and then
+// exits the program with a non-zero exit code.
+func (l *Logger) Fatal(v ...any) { l.fatal(1, l.entry(LevelFatal, v...)) }
+
+// Fatalf sends formatted log entry at the fatal log level, flushes all sinks
+// and then exits the program with a non-zero exit code.
+func (l *Logger) Fatalf(format string, v ...any) {
+	l.fatal(1, l.entryf(LevelFatal, format, v...))
+}
+
+// Panic sends log entry at the panic log level, flushes all sinks and then
+// panics with the log message.
+func (l *Logger) Panic(v ...any) { l.panic(1, l.entry(LevelPanic, v...)) }
+
+// Panicf sends formatted log entry at the panic log level, flushes all sinks
+// and then panics with the log message.
+func (l *Logger) Panicf(format string, v ...any) {
+	l.panic(1, l.entryf(LevelPanic, format, v...))
+}
+
+// Trace sends log entry at the trace log level.
+func (l *Logger) Trace(v ...any) { l.printLevel(1, LevelTrace, v...) }
+
+// Tracef sends formatted log entry at the trace log level.
+func (l *Logger) Tracef(format string, v ...any) { l.printLevelf(1, LevelTrace, format, v...) }
+
+// Debug sends log entry at the debug log level.
+func (l *Logger) Debug(v ...any) { l.printLevel(1, LevelDebug, v...) }
+
+// Debugf sends formatted log entry at the debug log level.
+func (l *Logger) Debugf(format string, v ...any) { l.printLevelf(1, LevelDebug, format, v...) }
+
+// Info sends log entry at the info log level.
+func (l *Logger) Info(v ...any) { l.printLevel(1, LevelInfo, v...) }
+
+// Infof sends formatted log entry at the info log level.
+func (l *Logger) Infof(format string, v ...any) { l.printLevelf(1, LevelInfo, format, v...) }
+
+// Warn sends log entry at the warn log level.
+func (l *Logger) Warn(v ...any) { l.printLevel(1, LevelWarn, v...) }
+
+// Warnf sends formatted log entry at the warn log level.
+func (l *Logger) Warnf(format string, v ...any) { l.printLevelf(1, LevelWarn, format, v...) }
+
+// Error sends log entry at the error log level.
+func (l *Logger) Error(v ...any) { l.printLevel(1, LevelError, v...) }
+
+// Errorf sends formatted log entry at the error log level.
+func (l *Logger) Errorf(format string, v ...any) { l.printLevelf(1, LevelError, format, v...) }
+
+// printLevel creates log entry at the given log level and sends it to the
+// loggers. The skip is the number of functions between the user code and
+// printLevel, it is used to get the caller.
+func (l *Logger) printLevel(skip int, level LogLevel, v ...any) {
+	l.printLevelContext(nil, skip+1, level, v...)
+}
+
+// printLevelf creates log entry at the given log level using format string
+// and sends it to the loggers. The skip is the number of functions between
+// the user code and printLevelf, it is used to get the caller.
+func (l *Logger) printLevelf(skip int, level LogLevel, format string, v ...any) {
+	l.printLevelfContext(nil, skip+1, level, format, v...)
+}
+
+// printLevelContext creates log entry at the given log level with the
+// context fields and sends it to the loggers. The ctx may be nil. The skip is
+// the number of functions between the user code and printLevelContext.
+func (l *Logger) printLevelContext(ctx context.Context, skip int, level LogLevel, v ...any) {
+	if !l.enabled(level) {
+		return
+	}
+	entry := l.entryContext(ctx, level, v...)
+	l.source(entry, skip+1)
+	l.send(entry)
+}
+
+// printLevelfContext creates log entry at the given log level using format
+// string with the context fields and sends it to the loggers. The ctx may be
+// nil. The skip is the number of functions between the user code and
+// printLevelfContext.
+func (l *Logger) printLevelfContext(ctx context.Context, skip int, level LogLevel, format string, v ...any) {
+	if !l.enabled(level) {
+		return
+	}
+	entry := l.entryfContext(ctx, level, format, v...)
+	l.source(entry, skip+1)
+	l.send(entry)
+}
+
+// fatal sends the log entry, flushes all sinks with the flush timeout and
+// then exits the program with a non-zero exit code. The skip is the number of
+// functions between the user code and fatal.
+func (l *Logger) fatal(skip int, entry *LogEntry) {
+	l.source(entry, skip+1)
+	l.send(entry)
+	l.flushTimeout()
+	os.Exit(1)
+}
+
+// panic sends the log entry, flushes all sinks with the flush timeout and
+// then panics with the log entry message. The skip is the number of
+// functions between the user code and panic.
+func (l *Logger) panic(skip int, entry *LogEntry) {
+	l.source(entry, skip+1)
+	l.send(entry)
+	l.flushTimeout()
+	panic(entry.Message)
+}
+
+// flushTimeout flushes all sinks and waits for the flush timeout at most.
+func (l *Logger) flushTimeout() {
+	ctx, cancel := context.WithTimeout(context.Background(), l.flushTimeoutDuration)
+	defer cancel()
+	if err := l.Flush(ctx); err != nil {
+		l.stdout.Println("error flushing sinks:", err)
+	}
+}
+
+// sentry creates log entry at the given log level and returns it as string.
+// The skip is the number of functions between the user code and sentry.
+func (l *Logger) sentry(skip int, level LogLevel, v ...any) string {
+	entry := l.entry(level, v...)
+	l.source(entry, skip+1)
+	return entry.String()
+}
+
+// sentryf creates log entry at the given log level using format string and
+// returns it as string. The skip is the number of functions between the user
+// code and sentryf.
+func (l *Logger) sentryf(skip int, level LogLevel, format string, v ...any) string {
+	entry := l.entryf(level, format, v...)
+	l.source(entry, skip+1)
+	return entry.String()
+}
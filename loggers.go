@@ -5,85 +5,231 @@
 package log
 
 import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
 	"slices"
 	"sync"
+	"time"
 )
 
 // loggersType is a struct that holds information about how to send log entries to
 // loggers.
 type loggersType struct {
 
-	// useStdoutLogger is a boolean that indicates whether to use the stdout logger
-	useStdoutLogger bool
+	// appShort is the short name of the application
+	appShort string
 
-	// useEsLogger is a boolean that indicates whether to use the Elasticsearch logger
-	useEsLogger bool
+	// appType is the type of the application, f.e. "DEV" or "PROD"
+	appType string
 
-	// useFailLogger is a boolean that indicates whether to use the fail logger
-	useFailLogger bool
+	// levelDefault is the log level used in standart log calls, f.e.
+	// log.Println
+	levelDefault levelValue
+
+	// addCaller is a boolean that indicates whether to add caller to log
+	// entries
+	addCaller bool
+
+	// callerSkip is a number of additional caller frames to skip
+	callerSkip int
+
+	// stackTrace is a boolean that indicates whether to add stack trace to
+	// the ERROR and above log entries
+	stackTrace bool
+
+	// flushTimeoutDuration is a maximum time to flush sinks before exit on
+	// the fatal and panic log levels
+	flushTimeoutDuration time.Duration
 
 	// filterLevels is a list of log levels to filter out.
 	filterLevels []LogLevel
 
-	// Elasticsearch logger
-	*es
+	// minLevel is a minimum log level of all sinks
+	minLevel levelVar
+
+	// stdout is a logger that writes to stdout
+	stdout *log.Logger
+
+	// sinks is a list of sinks log entries are sent to
+	sinks []*sinkQueue
 
-	// Fail logger
-	*file
+	// Sinks mutex, protects sinks from sending after close
+	mu sync.RWMutex
 
-	// Start wait group
-	wgStart sync.WaitGroup
+	// closed is true when sinks are closed
+	closed bool
 
 	// Close wait group
 	wgClose sync.WaitGroup
+
+	// stopSignals stops the signal level control, it is nil if the signal
+	// level control is not started
+	stopSignals func()
+
+	// stopDropReport stops the dropped log entries report, it is nil if the
+	// report is not started
+	stopDropReport func()
 }
 
-// newLoggers returns a new loggersType with an entry channel and two parameters set to default values.
-// It also starts a goroutine that handles log entries in the entry channel.
+// newLoggers returns a new loggersType with default values and without sinks.
 func newLoggers() (l *loggersType) {
 	// Create a new loggersType with default values
 	l = &loggersType{
-		useStdoutLogger: true,    // Set log to stdout by default
-		es:              &es{},   // Create a new Elasticsearch logger object
-		file:            &file{}, // Create a new fail logger object
+		stdout: log.New(os.Stdout, "", 0), // Create a new stdout logger
 	}
+	l.levelDefault.Set(LevelDefault) // Set default log level
 	return
 }
 
-// send sends a log entry to stdout logger and to the entry channel.
-// The entry channel is consumed by the entryHandler goroutine, which aggregates log entries
-// in a slice until either the slice reaches the maximum size (l.entriesToHold) or the time to hold
-// (l.timeToHold) expires. When either condition is met, it sends the aggregated log entries to
-// Elasticsearch using the sendToElasticsearch method.
-func (l *loggersType) send(entry *LogEntry) (err error) {
+// addSink adds the sink to the loggers and starts its queue handler. The
+// sink name is made unique with "-2", "-3", ... suffix if the loggers already
+// have a sink with the same name. The default spill directory is
+// "/tmp/APP_SHORT_NAME/spill".
+func (l *loggersType) addSink(sink Sink) {
+	name := sink.Name()
+	for n := 2; l.sink(name) != nil; n++ {
+		name = fmt.Sprintf("%s-%d", sink.Name(), n)
+	}
+	spillDir := filepath.Join(os.TempDir(), l.appShort, "spill")
+	l.sinks = append(l.sinks, newSinkQueue(sink, name, l.stdout, spillDir, &l.wgClose))
+}
+
+// enabled reports whether log entry with the given level is sent to at
+// least one sink. It is checked before the log entry is created, so the
+// suppressed log calls are cheap.
+func (l *loggersType) enabled(level LogLevel) bool {
+	if !l.minLevel.enabled(level) {
+		return false
+	}
 
 	// Filter logger entries by level
-	if l.filterLevels != nil {
-		if slices.Contains(l.filterLevels, entry.Level) {
-			return
+	if slices.Contains(l.filterLevels, level) {
+		return false
+	}
+
+	// Check sinks minimum levels
+	for _, sink := range l.sinks {
+		if sink.minLevel.enabled(level) {
+			return true
 		}
 	}
+	return false
+}
 
-	// Send to stdout logger. The stdout logger is a logger that writes to
-	// stdout.
-	if l.useStdoutLogger {
-		stdoutLogger.Println(entry.String())
+// sink returns the sink with the given name or nil if not found.
+func (l *loggersType) sink(name string) *sinkQueue {
+	for _, sink := range l.sinks {
+		if sink.Name() == name {
+			return sink
+		}
+	}
+	return nil
+}
+
+// send sends a log entry to all sinks which minimum level allows it.
+// Queued sinks receive log entry in their queue which is consumed by the sink
+// goroutine, so a slow sink does not stall the others. Synchronous sinks,
+// f.e. stdout, write log entry immediately.
+func (l *loggersType) send(entry *LogEntry) (err error) {
+
+	// Filter logger entries by level
+	if !l.minLevel.enabled(entry.Level) ||
+		slices.Contains(l.filterLevels, entry.Level) {
+		return
 	}
 
-	// Send to Elasticsearch channel which will send to elasticsearch
-	// The entry channel is a channel that receives log entries.
-	// It is consumed by the entryHandler goroutine, which aggregates log entries in a slice until
-	// either the slice reaches the maximum size (l.entriesToHold) or the time to hold (l.timeToHold) expires.
-	// When either condition is met, it sends the aggregated log entries to Elasticsearch using the
-	// sendToElasticsearch method.
-	if l.useEsLogger {
-		l.esEntryChannel <- entry
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	// Skip entries sent after close
+	if l.closed {
+		return
 	}
 
-	// Send to fail logger
-	if l.useFailLogger {
-		l.fileEntryChannel <- entry
+	// Send to sinks which minimum level allows this entry
+	for _, sink := range l.sinks {
+		if sink.minLevel.enabled(entry.Level) {
+			sink.send(entry)
+		}
 	}
 
 	return
 }
+
+// notice sends a log entry to all sinks regardless of log levels. It is used
+// for the logger own notices, f.e. log level changes, which should be visible
+// with any log level.
+func (l *loggersType) notice(entry *LogEntry) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	if l.closed {
+		return
+	}
+	for _, sink := range l.sinks {
+		sink.send(entry)
+	}
+}
+
+// flush flushes all sinks and waits until they are flushed or the context is
+// done. The queued sinks write all log entries sent before flush.
+func (l *loggersType) flush(ctx context.Context) error {
+	l.mu.RLock()
+	if l.closed {
+		l.mu.RUnlock()
+		return nil
+	}
+
+	// Send flush requests to queued sinks and flush synchronous sinks
+	var dones []chan struct{}
+	for _, sink := range l.sinks {
+		if sink.entries == nil {
+			sink.flush()
+			continue
+		}
+		done := make(chan struct{})
+		select {
+		case sink.flushes <- done:
+			dones = append(dones, done)
+		case <-ctx.Done():
+			l.mu.RUnlock()
+			return ctx.Err()
+		}
+	}
+	l.mu.RUnlock()
+
+	// Wait for queued sinks are flushed
+	for _, done := range dones {
+		select {
+		case <-done:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// close closes all sinks and waits for their goroutines to finish.
+func (l *loggersType) close() {
+	l.mu.Lock()
+	if l.closed {
+		l.mu.Unlock()
+		return
+	}
+	l.closed = true
+	if l.stopSignals != nil {
+		l.stopSignals()
+	}
+	if l.stopDropReport != nil {
+		l.stopDropReport()
+	}
+	for _, sink := range l.sinks {
+		sink.close()
+	}
+	l.mu.Unlock()
+
+	l.wgClose.Wait()
+}
@@ -0,0 +1,274 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// OverflowPolicy defines what a log call does when the sink queue is full.
+type OverflowPolicy int
+
+// Overflow policies
+const (
+	// OverflowBlock blocks the log call until the sink queue has free space.
+	OverflowBlock OverflowPolicy = iota
+
+	// OverflowBlockTimeout blocks the log call until the sink queue has free
+	// space or the OverflowConfig.Timeout expires, then the log entry is
+	// dropped.
+	OverflowBlockTimeout
+
+	// OverflowDropNewest drops the new log entry.
+	OverflowDropNewest
+
+	// OverflowDropOldest drops the oldest log entry from the sink queue and
+	// adds the new one.
+	OverflowDropOldest
+
+	// OverflowSpill writes the new log entry to the spill file on disk. The
+	// spilled log entries are written to the sink when its queue is empty.
+	OverflowSpill
+)
+
+// OverflowConfig is a sink queue overflow configuration.
+type OverflowConfig struct {
+	// Policy is the overflow policy, the default is OverflowBlock
+	Policy OverflowPolicy
+
+	// Timeout is the maximum time to block with OverflowBlockTimeout policy.
+	// If not set, Default is 100 milliseconds.
+	Timeout time.Duration
+
+	// SpillDir is a directory of the spill file used with OverflowSpill
+	// policy. Loggers must not share the spill directory of the sinks with
+	// the same name, the spilled entries of one are written by another.
+	// If not set, Default is "/tmp/APP_SHORT_NAME/spill".
+	SpillDir string
+}
+
+// SinkOverflow is an optional interface which may be implemented by Sink to
+// set its queue overflow configuration. If Sink does not implement it, log
+// calls block while its queue is full.
+type SinkOverflow interface {
+	Overflow() OverflowConfig
+}
+
+// Default interval of the dropped log entries reports.
+const defaultDropReportInterval = 10 * time.Second
+
+// enqueue sends log entry to the Sink queue using the Sink overflow policy.
+func (q *sinkQueue) enqueue(entry *LogEntry) {
+
+	// Send if the queue has free space
+	select {
+	case q.entries <- entry:
+		return
+	default:
+	}
+
+	// Queue is full
+	switch q.overflow.Policy {
+
+	case OverflowBlockTimeout:
+		timer := time.NewTimer(q.overflow.Timeout)
+		defer timer.Stop()
+		select {
+		case q.entries <- entry:
+		case <-timer.C:
+			q.drop(entry)
+		}
+
+	case OverflowDropNewest:
+		q.drop(entry)
+
+	case OverflowDropOldest:
+		for {
+			select {
+			case q.entries <- entry:
+				return
+			default:
+			}
+			select {
+			case old := <-q.entries:
+				q.drop(old)
+			default:
+			}
+		}
+
+	case OverflowSpill:
+		if err := q.spill.push(entry); err != nil {
+			q.stdout.Printf("error spilling %s sink entry: %v", q.Name(), err)
+			q.drop(entry)
+		}
+
+	default:
+		q.entries <- entry
+	}
+}
+
+// mayDrop returns true if the Sink overflow policy may drop log entries.
+func (q *sinkQueue) mayDrop() bool {
+	return q.overflow.Policy != OverflowBlock
+}
+
+// drop counts dropped log entry and releases it from the Sink spool.
+func (q *sinkQueue) drop(entry *LogEntry) {
+	q.dropped.Add(1)
+	q.droppedRecent.Add(1)
+	if q.spool != nil {
+		q.spool.Release(entry)
+	}
+}
+
+// unspill writes the spilled log entries to the Sink.
+func (q *sinkQueue) unspill() {
+	if q.spill == nil || q.spill.len() == 0 {
+		return
+	}
+	entries, err := q.spill.pop()
+	if err != nil {
+		q.stdout.Printf("error reading %s sink spill file: %v", q.Name(), err)
+	}
+	for _, entry := range entries {
+		q.write(entry)
+	}
+}
+
+// spillFile is an on-disk overflow of the sink queue, one JSON log entry per
+// line.
+type spillFile struct {
+	path string
+	mu   sync.Mutex
+	f    *os.File
+	n    atomic.Int64
+}
+
+// newSpillFile returns the spill file of the sink in the directory. The
+// existing spill file left after the previous run is kept and written to the
+// sink too.
+func newSpillFile(dir, name string) *spillFile {
+	s := &spillFile{path: filepath.Join(dir, name+".spill")}
+	if info, err := os.Stat(s.path); err == nil && info.Size() > 0 {
+		s.n.Store(1)
+	}
+	return s
+}
+
+// len returns the number of spilled log entries. It may be not zero for an
+// empty spill file left after the previous run.
+func (s *spillFile) len() int64 { return s.n.Load() }
+
+// push appends log entry to the spill file.
+func (s *spillFile) push(entry *LogEntry) (err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Open spill file
+	if s.f == nil {
+		os.MkdirAll(filepath.Dir(s.path), 0755)
+		s.f, err = os.OpenFile(s.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+		if err != nil {
+			s.f = nil
+			return
+		}
+	}
+
+	// Write log entry
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	if _, err = s.f.Write(append(data, '\n')); err != nil {
+		return
+	}
+	s.n.Add(1)
+	return
+}
+
+// pop reads and removes all spilled log entries.
+func (s *spillFile) pop() (entries []*LogEntry, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// Close spill file
+	if s.f != nil {
+		s.f.Close()
+		s.f = nil
+	}
+	s.n.Store(0)
+
+	// Read and remove spill file
+	f, err := os.Open(s.path)
+	if err != nil {
+		return
+	}
+	defer os.Remove(s.path)
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 16<<20)
+	for scanner.Scan() {
+		entry := new(LogEntry)
+		if err := json.Unmarshal(scanner.Bytes(), entry); err != nil {
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	err = scanner.Err()
+	return
+}
+
+// Dropped returns the number of log entries dropped by the sink with the
+// given name because its queue was full. It returns 0 if the sink is not
+// found.
+func (l *Logger) Dropped(sink string) uint64 {
+	if q := l.sink(sink); q != nil {
+		return q.dropped.Load()
+	}
+	return 0
+}
+
+// startDropReport starts the goroutine which sends the "dropped N entries"
+// warning every interval for the sinks which dropped log entries during this
+// interval. The goroutine is stopped when the Logger is closed.
+func (l *Logger) startDropReport(interval time.Duration) {
+	done := make(chan struct{})
+	l.stopDropReport = func() { close(done) }
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				l.reportDropped(interval)
+			case <-done:
+				return
+			}
+		}
+	}()
+}
+
+// reportDropped sends the warning for every sink which dropped log entries
+// since the previous report.
+func (l *Logger) reportDropped(interval time.Duration) {
+	for _, q := range l.sinks {
+		n := q.droppedRecent.Swap(0)
+		if n == 0 {
+			continue
+		}
+		l.notice(l.newEntry(nil, LevelWarn,
+			fmt.Sprintf("dropped %d entries in last %s", n, interval),
+			Fields{"sink": q.Name(), "dropped": n}, nil))
+	}
+}
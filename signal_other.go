@@ -0,0 +1,11 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build !unix
+
+package log
+
+// startSignals does nothing, the signal level control is not supported on
+// non-Unix systems.
+func (l *Logger) startSignals() {}
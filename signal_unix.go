@@ -0,0 +1,43 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package log
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// startSignals starts the goroutine which changes the minimum log level on
+// SIGUSR1 (more verbose) and SIGUSR2 (less verbose) signals. The goroutine is
+// stopped when the Logger is closed.
+func (l *Logger) startSignals() {
+	signals := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	signal.Notify(signals, syscall.SIGUSR1, syscall.SIGUSR2)
+
+	l.stopSignals = func() {
+		signal.Stop(signals)
+		close(done)
+	}
+
+	go func() {
+		for {
+			select {
+			case sig := <-signals:
+				switch sig {
+				case syscall.SIGUSR1:
+					l.stepMinLevel(-1, "SIGUSR1")
+				case syscall.SIGUSR2:
+					l.stepMinLevel(1, "SIGUSR2")
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+}
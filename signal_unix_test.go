@@ -0,0 +1,40 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+//go:build unix
+
+package log
+
+import (
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestSignalLevelControl(t *testing.T) {
+
+	l := New(Config{DoesNotShowInitMessage: true, MinLevel: LevelInfo,
+		SignalLevelControl: true, Sinks: []Sink{&memSink{}},
+	})
+	defer l.Close()
+
+	// waitLevel waits for the minimum level change
+	waitLevel := func(want LogLevel) {
+		for range 100 {
+			if l.MinLevel() == want {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		t.Fatalf("got level %s, want %s", l.MinLevel(), want)
+	}
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR2)
+	waitLevel(LevelWarn)
+
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	waitLevel(LevelInfo)
+	syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+	waitLevel(LevelDebug)
+}
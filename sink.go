@@ -0,0 +1,300 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"log"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Sink is a destination of log entries, f.e. stdout, file or Elasticsearch.
+//
+// Every Sink gets its own buffered queue and goroutine, so a slow Sink does
+// not stall the others. The Write, Flush and Close methods of a queued Sink
+// are called from this goroutine only. Sinks which set zero queue size with
+// SinkOptions are called synchronously from the log calls and must be safe
+// for concurrent use. The stdout sink is synchronous by default, so the last
+// log lines are written even if the Logger is not closed before the program
+// exits, see Config.StdoutQueueSize.
+type Sink interface {
+	// Name returns the Sink name, f.e. "stdout" or "elasticsearch". The
+	// sinks with the same name in one Logger get "-2", "-3", ... suffixes,
+	// f.e. in Logger.SetSinkMinLevel and LevelHandler.
+	Name() string
+
+	// Write writes log entry to the Sink.
+	Write(entry *LogEntry) error
+
+	// Flush writes buffered log entries, if any.
+	Flush() error
+
+	// Close flushes and closes the Sink. Write is not called after Close.
+	Close() error
+}
+
+// SinkOptions is an optional interface which may be implemented by Sink to
+// change its queue parameters.
+type SinkOptions interface {
+	// QueueSize returns the Sink queue size. If it is 0, the Sink is called
+	// synchronously without queue and goroutine.
+	QueueSize() int
+
+	// FlushInterval returns the interval of periodic Flush calls. If it is 0,
+	// Flush is called only when the Sink is closed.
+	FlushInterval() time.Duration
+}
+
+// SinkLevel is an optional interface which may be implemented by Sink to set
+// its initial minimum log level. Log entries below this level are not sent
+// to the Sink. The minimum level may be changed later with
+// Logger.SetSinkMinLevel.
+type SinkLevel interface {
+	MinLevel() LogLevel
+}
+
+// SinkSpool is an optional interface which may be implemented by queued Sink
+// to persist log entries before they are added to its queue, f.e. to a
+// write-ahead log on disk. Spool and Release are called from the log calls
+// and must be safe for concurrent use.
+type SinkSpool interface {
+	// Spool persists log entry before it is added to the Sink queue.
+	Spool(entry *LogEntry) error
+
+	// Release releases the spooled log entry which is not written to the
+	// Sink, f.e. dropped by the queue overflow policy.
+	Release(entry *LogEntry)
+}
+
+// Default sink queue size used if Sink does not implement SinkOptions.
+const defaultQueueSize = 100
+
+// sinkQueue is a Sink with its queue and goroutine.
+type sinkQueue struct {
+	Sink
+
+	// name is the unique Sink name in the Logger
+	name string
+
+	// entries is a Sink queue, it is nil for synchronous sinks
+	entries chan *LogEntry
+
+	// flushes is a channel of flush requests, the request channel is closed
+	// when the Sink is flushed
+	flushes chan chan struct{}
+
+	// flushInterval is an interval of periodic Flush calls
+	flushInterval time.Duration
+
+	// minLevel is the Sink minimum log level
+	minLevel levelVar
+
+	// stdout is a logger to print Sink errors
+	stdout *log.Logger
+
+	// overflow is the Sink queue overflow configuration
+	overflow OverflowConfig
+
+	// spool is the Sink write-ahead spool, it is nil if the Sink does not
+	// implement SinkSpool
+	spool SinkSpool
+
+	// spill is the Sink spill file, it is nil if the overflow policy is not
+	// OverflowSpill
+	spill *spillFile
+
+	// dropped is the total number of dropped log entries and droppedRecent
+	// is the number of log entries dropped since the last report
+	dropped, droppedRecent atomic.Uint64
+}
+
+// newSinkQueue creates a new sinkQueue for the Sink with the unique name and
+// starts its goroutine. The spillDir is used if the Sink overflow
+// configuration has no SpillDir.
+func newSinkQueue(sink Sink, name string, stdout *log.Logger, spillDir string,
+	wg *sync.WaitGroup) *sinkQueue {
+	q := &sinkQueue{Sink: sink, name: name, stdout: stdout}
+
+	// Get queue options
+	queueSize := defaultQueueSize
+	if opts, ok := sink.(SinkOptions); ok {
+		queueSize = opts.QueueSize()
+		q.flushInterval = opts.FlushInterval()
+	}
+
+	// Set minimum log level
+	if sl, ok := sink.(SinkLevel); ok {
+		q.minLevel.Set(sl.MinLevel())
+	}
+
+	// Synchronous sink
+	if queueSize <= 0 {
+		return q
+	}
+
+	// Set write-ahead spool
+	q.spool, _ = sink.(SinkSpool)
+
+	// Set queue overflow configuration
+	if so, ok := sink.(SinkOverflow); ok {
+		q.overflow = so.Overflow()
+	}
+	switch q.overflow.Policy {
+	case OverflowBlockTimeout:
+		if q.overflow.Timeout <= 0 {
+			q.overflow.Timeout = 100 * time.Millisecond
+		}
+	case OverflowSpill:
+		if q.overflow.SpillDir == "" {
+			q.overflow.SpillDir = spillDir
+		}
+		q.spill = newSpillFile(q.overflow.SpillDir, name)
+	}
+
+	// Create queue and start its handler
+	q.entries = make(chan *LogEntry, queueSize)
+	q.flushes = make(chan chan struct{})
+	wg.Add(1)
+	go q.entryHandler(wg)
+
+	return q
+}
+
+// Name returns the unique Sink name in the Logger.
+func (q *sinkQueue) Name() string { return q.name }
+
+// send sends log entry to the Sink queue or writes it to the synchronous
+// Sink. If the queue is full, the Sink overflow policy is applied.
+func (q *sinkQueue) send(entry *LogEntry) {
+	if q.entries == nil {
+		q.write(entry)
+		return
+	}
+	if q.spool != nil {
+		if err := q.spool.Spool(entry); err != nil {
+			q.stdout.Printf("error spooling %s sink entry: %v", q.Name(), err)
+		}
+	}
+	q.enqueue(entry)
+}
+
+// close closes the Sink queue. Synchronous sinks are flushed and closed here,
+// queued sinks are flushed and closed by the entryHandler goroutine.
+func (q *sinkQueue) close() {
+	if q.entries == nil {
+		q.closeSink()
+		return
+	}
+	close(q.entries)
+}
+
+// entryHandler is a goroutine that consumes log entries from the Sink queue,
+// writes them to the Sink and periodically flushes the Sink. The spilled log
+// entries are written when the queue is empty. When the queue is closed it
+// closes the Sink and exits.
+func (q *sinkQueue) entryHandler(wg *sync.WaitGroup) {
+	defer wg.Done()
+
+	// Create flush ticker
+	var tick <-chan time.Time
+	if q.flushInterval > 0 {
+		ticker := time.NewTicker(q.flushInterval)
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	// Loop until the queue is closed
+	for {
+		select {
+		case entry, ok := <-q.entries:
+			if !ok {
+				q.unspill()
+				q.closeSink()
+				return
+			}
+			q.write(entry)
+			if len(q.entries) == 0 {
+				q.unspill()
+			}
+
+		case done := <-q.flushes:
+			// Write log entries queued before the flush request
+			for range len(q.entries) {
+				entry, ok := <-q.entries
+				if !ok {
+					break
+				}
+				q.write(entry)
+			}
+			q.unspill()
+			q.flush()
+			close(done)
+
+		case <-tick:
+			q.unspill()
+			q.flush()
+		}
+	}
+}
+
+// flush flushes the Sink and prints error if any.
+func (q *sinkQueue) flush() {
+	if err := q.Flush(); err != nil {
+		q.stdout.Printf("error flushing %s sink: %v", q.Name(), err)
+	}
+}
+
+// write writes log entry to the Sink and prints error if any.
+func (q *sinkQueue) write(entry *LogEntry) {
+	if err := q.Write(entry); err != nil {
+		q.stdout.Printf("error writing to %s sink: %v", q.Name(), err)
+	}
+}
+
+// closeSink closes the Sink and prints error if any.
+func (q *sinkQueue) closeSink() {
+	if err := q.Close(); err != nil {
+		q.stdout.Printf("error closing %s sink: %v", q.Name(), err)
+	}
+}
+
+// stdoutSink is a Sink which writes log entries to stdout. It is synchronous
+// if the queue size is not positive.
+type stdoutSink struct {
+	stdout    *log.Logger
+	minLevel  LogLevel
+	formatter Formatter
+	queueSize int
+}
+
+func (s *stdoutSink) Name() string                 { return "stdout" }
+func (s *stdoutSink) MinLevel() LogLevel           { return s.minLevel }
+func (s *stdoutSink) Flush() error                 { return nil }
+func (s *stdoutSink) Close() error                 { return nil }
+func (s *stdoutSink) QueueSize() int               { return s.queueSize }
+func (s *stdoutSink) FlushInterval() time.Duration { return 0 }
+
+// Write writes log entry formatted with the stdout sink formatter to stdout.
+func (s *stdoutSink) Write(entry *LogEntry) error {
+	data, err := s.formatter.Format(entry)
+	if err != nil {
+		return err
+	}
+	return s.stdout.Output(0, string(data))
+}
+
+// slogSink is a Sink which sends log entries to the slog handler.
+type slogSink struct{ h slog.Handler }
+
+func (s *slogSink) Name() string { return "slog" }
+func (s *slogSink) Flush() error { return nil }
+func (s *slogSink) Close() error { return nil }
+
+// Write sends log entry to the slog handler.
+func (s *slogSink) Write(entry *LogEntry) error {
+	return sendToSlog(s.h, entry)
+}
@@ -0,0 +1,241 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// memSink is a Sink which holds log entries in memory.
+type memSink struct {
+	entries []*LogEntry
+	closed  bool
+}
+
+func (s *memSink) Name() string                { return "mem" }
+func (s *memSink) Write(entry *LogEntry) error { s.entries = append(s.entries, entry); return nil }
+func (s *memSink) Flush() error                { return nil }
+func (s *memSink) Close() error                { s.closed = true; return nil }
+
+func TestSinks(t *testing.T) {
+
+	// Create logger with custom sink
+	sink := &memSink{}
+	l := New(Config{DoesNotShowInitMessage: true, Sinks: []Sink{sink}})
+
+	l.Info("first")
+	l.Warn("second")
+	l.Close()
+
+	// Entries sent after close are dropped
+	l.Error("after close")
+
+	if !sink.closed {
+		t.Fatal("sink is not closed")
+	}
+	if len(sink.entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(sink.entries))
+	}
+	if sink.entries[0].Message != "first" || sink.entries[1].Level != LevelWarn {
+		t.Fatalf("got wrong entries: %v, %v", sink.entries[0], sink.entries[1])
+	}
+}
+
+func TestMinLevel(t *testing.T) {
+
+	// Create logger with two sinks with different minimum levels
+	debug, warn := &memSink{}, &memSink{}
+	l := New(Config{DoesNotShowInitMessage: true, Sinks: []Sink{debug, warn}})
+	l.SetSinkMinLevel("mem", LevelDebug)
+	l.sinks[1].minLevel.Set(LevelWarn)
+
+	if err := l.SetSinkMinLevel("unknown", LevelInfo); err == nil {
+		t.Fatal("unknown sink level set without error")
+	}
+
+	l.Debug("debug")
+	l.Info("info")
+	l.Error("error")
+
+	// Global minimum level suppresses entries for all sinks
+	l.SetMinLevel(LevelError)
+	if l.Enabled(LevelWarn) {
+		t.Fatal("warn level enabled")
+	}
+	l.Warn("warn")
+	l.Close()
+
+	if len(debug.entries) != 3 {
+		t.Fatalf("got %d debug sink entries, want 3", len(debug.entries))
+	}
+	if len(warn.entries) != 1 || warn.entries[0].Level != LevelError {
+		t.Fatalf("got %d warn sink entries, want 1", len(warn.entries))
+	}
+}
+
+func TestPanicFlush(t *testing.T) {
+
+	sink := &memSink{}
+	l := New(Config{DoesNotShowInitMessage: true, Sinks: []Sink{sink}})
+	defer l.Close()
+
+	// Panic flushes sinks before panicking
+	func() {
+		defer func() {
+			if r := recover(); r != "panic message" {
+				t.Fatalf("got panic %v, want panic message", r)
+			}
+		}()
+		l.Trace("trace")
+		l.Panic("panic message")
+	}()
+
+	if len(sink.entries) != 2 || sink.entries[1].Level != LevelPanic {
+		t.Fatalf("got wrong entries after panic: %v", sink.entries)
+	}
+}
+
+// slowSink is a memSink with one entry queue which blocks in Write until the
+// gate is closed.
+type slowSink struct {
+	memSink
+	overflow OverflowConfig
+	started  chan struct{}
+	gate     chan struct{}
+}
+
+func (s *slowSink) Name() string                 { return "slow" }
+func (s *slowSink) QueueSize() int               { return 1 }
+func (s *slowSink) FlushInterval() time.Duration { return 0 }
+func (s *slowSink) Overflow() OverflowConfig     { return s.overflow }
+
+func (s *slowSink) Write(entry *LogEntry) error {
+	if len(s.entries) == 0 {
+		close(s.started)
+		<-s.gate
+	}
+	return s.memSink.Write(entry)
+}
+
+func TestOverflow(t *testing.T) {
+	for _, test := range []struct {
+		name    string
+		policy  OverflowPolicy
+		want    []string
+		dropped uint64
+	}{
+		{"block-timeout", OverflowBlockTimeout, []string{"1", "2"}, 2},
+		{"drop-newest", OverflowDropNewest, []string{"1", "2"}, 2},
+		{"drop-oldest", OverflowDropOldest, []string{"1", "4"}, 2},
+		{"spill", OverflowSpill, []string{"1", "2", "3", "4"}, 0},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			slow := &slowSink{
+				overflow: OverflowConfig{
+					Policy:   test.policy,
+					Timeout:  10 * time.Millisecond,
+					SpillDir: t.TempDir(),
+				},
+				started: make(chan struct{}),
+				gate:    make(chan struct{}),
+			}
+			mem := &memSink{}
+			l := New(Config{
+				DoesNotShowInitMessage: true,
+				DropReportInterval:     -1,
+				Sinks:                  []Sink{slow, mem},
+			})
+
+			// First entry blocks the sink, second fills the queue, others
+			// overflow
+			l.Info("1")
+			<-slow.started
+			for _, msg := range []string{"2", "3", "4"} {
+				l.Info(msg)
+			}
+			if n := l.Dropped("slow"); n != test.dropped {
+				t.Fatalf("got %d dropped entries, want %d", n, test.dropped)
+			}
+			close(slow.gate)
+			l.Flush(context.Background())
+
+			// Report dropped entries
+			l.reportDropped(10 * time.Second)
+			l.Close()
+
+			var got []string
+			for _, entry := range slow.entries {
+				if entry.Level == LevelInfo {
+					got = append(got, entry.Message)
+				}
+			}
+			if !slices.Equal(got, test.want) {
+				t.Fatalf("got entries %v, want %v", got, test.want)
+			}
+			report := mem.entries[len(mem.entries)-1]
+			if test.dropped > 0 && report.Message != "dropped 2 entries in last 10s" {
+				t.Fatalf("got wrong report: %s", report.Message)
+			}
+		})
+	}
+}
+
+func TestSpillDir(t *testing.T) {
+
+	// Loggers of different applications do not share spill files
+	var paths []string
+	for _, app := range []string{"app1", "app2"} {
+		l := New(Config{
+			AppShort:               app,
+			DoesNotShowInitMessage: true,
+			Sinks: []Sink{&slowSink{
+				overflow: OverflowConfig{Policy: OverflowSpill},
+			}},
+		})
+		paths = append(paths, l.sink("slow").spill.path)
+		l.Close()
+	}
+	want := filepath.Join(os.TempDir(), "app1", "spill", "slow.spill")
+	if paths[0] != want || paths[0] == paths[1] {
+		t.Fatalf("got spill files %v, want %s first", paths, want)
+	}
+}
+
+func TestStdoutQueue(t *testing.T) {
+
+	// Stdout sink is synchronous by default and queued with positive size
+	for _, test := range []struct {
+		queueSize int
+		want      int
+	}{{0, 0}, {10, 10}, {-1, 0}} {
+		l := New(Config{DoesNotShowInitMessage: true, UseStdout: true,
+			StdoutQueueSize: test.queueSize})
+		if n := cap(l.sink("stdout").entries); n != test.want {
+			t.Errorf("got stdout queue size %d, want %d", n, test.want)
+		}
+		l.Close()
+	}
+}
+
+func TestDropReportStart(t *testing.T) {
+
+	// Report is started only if some sink may drop log entries
+	for _, test := range []struct {
+		policy OverflowPolicy
+		want   bool
+	}{{OverflowBlock, false}, {OverflowDropNewest, true}} {
+		l := New(Config{DoesNotShowInitMessage: true, UseStdout: true,
+			Sinks: []Sink{&slowSink{overflow: OverflowConfig{Policy: test.policy}}}})
+		if started := l.stopDropReport != nil; started != test.want {
+			t.Errorf("policy %d: got report started %v, want %v", test.policy, started, test.want)
+		}
+		l.Close()
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"sort"
+	"time"
+)
+
+// SlogHandler is a slog.Handler which sends slog records to the Logger
+// loggers (stdout, file and Elasticsearch).
+//
+// The slog levels are mapped to the LogLevel, the record attributes are added
+// to the LogEntry.Fields and the slog groups are added as nested Fields.
+type SlogHandler struct {
+	l *Logger
+
+	// attrs is a list of attributes added with WithAttrs
+	attrs []groupAttrs
+
+	// groups is a current groups path added with WithGroup
+	groups []string
+}
+
+// groupAttrs is a list of attributes added with WithAttrs inside groups.
+type groupAttrs struct {
+	groups []string
+	attrs  []slog.Attr
+}
+
+// NewSlogHandler returns a new slog.Handler which sends slog records to the
+// Logger l. If l is nil, the default Logger is used.
+func NewSlogHandler(l *Logger) *SlogHandler {
+	if l == nil {
+		l = Default()
+	}
+	return &SlogHandler{l: l}
+}
+
+// SetSlogDefault makes the slog handler of the Logger l the default slog
+// handler. If l is nil, the default Logger is used.
+//
+// The slog.SetDefault redirects the standard log package output to the slog
+// handler, so log.Println messages are sent with the INFO level after this
+// call.
+func SetSlogDefault(l *Logger) {
+	slog.SetDefault(slog.New(NewSlogHandler(l)))
+}
+
+// Enabled reports whether the handler handles records at the given level.
+func (h *SlogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return h.l.enabled(logLevel(level))
+}
+
+// Handle converts the slog record to LogEntry and sends it to the loggers.
+// The context fields are added to the LogEntry fields.
+func (h *SlogHandler) Handle(ctx context.Context, r slog.Record) error {
+
+	// Make fields from attributes added with WithAttrs
+	var fields Fields
+	for _, ga := range h.attrs {
+		fields = addAttrs(fields, ga.groups, ga.attrs)
+	}
+
+	// Add record attributes
+	attrs := make([]slog.Attr, 0, r.NumAttrs())
+	r.Attrs(func(a slog.Attr) bool {
+		attrs = append(attrs, a)
+		return true
+	})
+	fields = addAttrs(fields, h.groups, attrs)
+
+	// Create log entry with record time, caller and stack trace
+	entry := h.l.entryContext(ctx, logLevel(r.Level), r.Message, fields)
+	if !r.Time.IsZero() {
+		entry.Timestamp = r.Time.Format(time.RFC3339Nano)
+	}
+	h.l.slogSource(entry, r.PC)
+
+	return h.l.send(entry)
+}
+
+// WithAttrs returns a new handler whose attributes consists of h attributes
+// followed by attrs.
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+	h2 := *h
+	h2.attrs = append(slices.Clip(h.attrs), groupAttrs{h.groups, attrs})
+	return &h2
+}
+
+// WithGroup returns a new handler with the given group appended to the h
+// groups.
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.groups = append(slices.Clip(h.groups), name)
+	return &h2
+}
+
+// addAttrs adds attributes to the fields inside the groups path. The fields
+// map is created if it is nil. Group maps are created only if there is at
+// least one non empty attribute in it.
+func addAttrs(fields Fields, groups []string, attrs []slog.Attr) Fields {
+	for _, a := range attrs {
+		a.Value = a.Value.Resolve()
+
+		// Skip empty attributes and empty groups
+		if a.Equal(slog.Attr{}) ||
+			a.Value.Kind() == slog.KindGroup && len(a.Value.Group()) == 0 {
+			continue
+		}
+
+		// Get or create group map
+		if fields == nil {
+			fields = Fields{}
+		}
+		m := fields
+		for _, g := range groups {
+			sub, ok := m[g].(Fields)
+			if !ok {
+				sub = Fields{}
+				m[g] = sub
+			}
+			m = sub
+		}
+
+		// Add attribute
+		switch {
+		case a.Value.Kind() == slog.KindGroup && a.Key == "":
+			addAttrs(m, nil, a.Value.Group())
+		case a.Value.Kind() == slog.KindGroup:
+			addAttrs(m, []string{a.Key}, a.Value.Group())
+		default:
+			m[a.Key] = a.Value.Any()
+		}
+	}
+	return fields
+}
+
+// The slog levels of the log levels which does not exist in slog.
+const (
+	slogLevelTrace = slog.LevelDebug - 4
+	slogLevelPanic = slog.LevelError + 4
+	slogLevelFatal = slog.LevelError + 8
+)
+
+// logLevel converts slog level to LogLevel.
+func logLevel(level slog.Level) LogLevel {
+	switch {
+	case level < slog.LevelDebug:
+		return LevelTrace
+	case level < slog.LevelInfo:
+		return LevelDebug
+	case level < slog.LevelWarn:
+		return LevelInfo
+	case level < slog.LevelError:
+		return LevelWarn
+	case level < slogLevelPanic:
+		return LevelError
+	case level < slogLevelFatal:
+		return LevelPanic
+	default:
+		return LevelFatal
+	}
+}
+
+// slogLevel converts LogLevel to slog level.
+func slogLevel(level LogLevel) slog.Level {
+	switch level {
+	case LevelTrace:
+		return slogLevelTrace
+	case LevelDebug:
+		return slog.LevelDebug
+	case LevelWarn:
+		return slog.LevelWarn
+	case LevelError:
+		return slog.LevelError
+	case LevelPanic:
+		return slogLevelPanic
+	case LevelFatal:
+		return slogLevelFatal
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// sendToSlog sends log entry to the slog handler h.
+func sendToSlog(h slog.Handler, entry *LogEntry) error {
+	ctx := context.Background()
+	level := slogLevel(entry.Level)
+	if !h.Enabled(ctx, level) {
+		return nil
+	}
+
+	// Get entry time
+	t, err := time.Parse(time.RFC3339Nano, entry.Timestamp)
+	if err != nil {
+		t = time.Now()
+	}
+
+	// Create slog record with fields
+	r := slog.NewRecord(t, level, entry.Message, 0)
+	r.AddAttrs(fieldsAttrs(entry.Fields)...)
+
+	return h.Handle(ctx, r)
+}
+
+// fieldsAttrs converts fields to slog attributes sorted by key. Nested fields
+// are converted to slog groups.
+func fieldsAttrs(fields map[string]any) []slog.Attr {
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	attrs := make([]slog.Attr, 0, len(keys))
+	for _, k := range keys {
+		switch v := fields[k].(type) {
+		case Fields:
+			attrs = append(attrs, slog.Attr{Key: k, Value: slog.GroupValue(fieldsAttrs(v)...)})
+		case map[string]any:
+			attrs = append(attrs, slog.Attr{Key: k, Value: slog.GroupValue(fieldsAttrs(v)...)})
+		default:
+			attrs = append(attrs, slog.Any(k, v))
+		}
+	}
+	return attrs
+}
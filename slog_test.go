@@ -0,0 +1,37 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+
+	// Create logger which sends entries to the slog text handler
+	var buf bytes.Buffer
+	l := New(Config{DoesNotShowInitMessage: true,
+		SlogHandler: slog.NewTextHandler(&buf, nil),
+	})
+
+	// Log with slog using the logger slog handler
+	logger := slog.New(NewSlogHandler(l)).With("app", "test").WithGroup("req")
+	logger.Warn("slog test", "id", 1, slog.Group("user", "name", "alice"))
+
+	// Close logger to flush slog sink
+	l.Close()
+
+	// Check output of the slog text handler
+	out := buf.String()
+	for _, s := range []string{"level=WARN", `msg="slog test"`, "app=test",
+		"req.id=1", "req.user.name=alice"} {
+		if !strings.Contains(out, s) {
+			t.Fatalf("output %q does not contain %q", out, s)
+		}
+	}
+}
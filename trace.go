@@ -0,0 +1,121 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// SpanContext is a W3C trace context of the log entry. It is compatible with
+// OpenTelemetry trace and span IDs.
+type SpanContext struct {
+	// TraceID is a 32 hex digits trace ID
+	TraceID string
+
+	// SpanID is a 16 hex digits span (parent) ID
+	SpanID string
+
+	// Sampled is the W3C sampled trace flag
+	Sampled bool
+}
+
+// TraceExtractor is a function which returns the trace context from the
+// context, f.e. OpenTelemetry span context converted to SpanContext. It
+// returns false if the context has no trace context.
+type TraceExtractor func(ctx context.Context) (SpanContext, bool)
+
+// traceKey is a context key of the trace context.
+type traceKey struct{}
+
+// traceExtractors is a list of registered trace extractors.
+var traceExtractors registry[TraceExtractor]
+
+// ParseTraceparent parses the W3C traceparent header value, f.e.
+// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+func ParseTraceparent(traceparent string) (sc SpanContext, err error) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || len(parts[1]) != 32 ||
+		len(parts[2]) != 16 || len(parts[3]) != 2 {
+		err = fmt.Errorf("invalid traceparent: %q", traceparent)
+		return
+	}
+
+	// Version ff is invalid, version 00 must have exactly 4 parts
+	if parts[0] == "ff" || parts[0] == "00" && len(parts) != 4 {
+		err = fmt.Errorf("invalid traceparent version: %q", traceparent)
+		return
+	}
+
+	// Check hex values, all zero trace and span IDs are invalid
+	for _, part := range parts[:4] {
+		if _, err = hex.DecodeString(part); err != nil || part != strings.ToLower(part) {
+			err = fmt.Errorf("invalid traceparent: %q", traceparent)
+			return
+		}
+	}
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		err = fmt.Errorf("invalid traceparent ids: %q", traceparent)
+		return
+	}
+
+	flags, _ := hex.DecodeString(parts[3])
+	sc = SpanContext{TraceID: parts[1], SpanID: parts[2], Sampled: flags[0]&1 == 1}
+	return
+}
+
+// Traceparent returns the W3C traceparent header value of the trace context.
+func (sc SpanContext) Traceparent() string {
+	flags := "00"
+	if sc.Sampled {
+		flags = "01"
+	}
+	return "00-" + sc.TraceID + "-" + sc.SpanID + "-" + flags
+}
+
+// ContextWithTrace returns a copy of the parent context with the trace
+// context. The trace context is added to the log entries made with the
+// Context log functions, f.e. InfoContext.
+func ContextWithTrace(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, traceKey{}, sc)
+}
+
+// TraceFromContext returns the trace context from the context. It uses the
+// trace context added with ContextWithTrace or, if there is no one, the
+// registered trace extractors.
+func TraceFromContext(ctx context.Context) (SpanContext, bool) {
+	if sc, ok := ctx.Value(traceKey{}).(SpanContext); ok {
+		return sc, true
+	}
+	for _, extract := range traceExtractors.load() {
+		if sc, ok := extract(ctx); ok {
+			return sc, true
+		}
+	}
+	return SpanContext{}, false
+}
+
+// RegisterTraceExtractor registers the function which returns the trace
+// context from the context. It may be used to get trace IDs from a tracing
+// library, f.e. OpenTelemetry, without adding it to this package
+// dependencies.
+func RegisterTraceExtractor(extractor TraceExtractor) {
+	traceExtractors.register(extractor)
+}
+
+// TraceMiddleware returns http.Handler which adds the trace context from the
+// incoming traceparent header to the request context. Requests without valid
+// traceparent header are passed as is.
+func TraceMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sc, err := ParseTraceparent(r.Header.Get("traceparent")); err == nil {
+			r = r.WithContext(ContextWithTrace(r.Context(), sc))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
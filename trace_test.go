@@ -0,0 +1,62 @@
+// Copyright 2025 Kirill Scherba <kirill@scherba.ru>. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package log
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	const traceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+	sc, err := ParseTraceparent(traceparent)
+	if err != nil || sc.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" ||
+		sc.SpanID != "00f067aa0ba902b7" || !sc.Sampled {
+		t.Fatalf("got %+v, %v", sc, err)
+	}
+	if sc.Traceparent() != traceparent {
+		t.Fatalf("got traceparent %s, want %s", sc.Traceparent(), traceparent)
+	}
+
+	for _, invalid := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+	} {
+		if _, err := ParseTraceparent(invalid); err == nil {
+			t.Fatalf("invalid traceparent %q parsed without error", invalid)
+		}
+	}
+}
+
+func TestTraceMiddleware(t *testing.T) {
+
+	sink := &memSink{}
+	l := New(Config{DoesNotShowInitMessage: true, Sinks: []Sink{sink}})
+
+	// Log inside handler wrapped with the trace middleware
+	h := TraceMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		l.InfoContext(r.Context(), "request")
+	}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	l.Close()
+
+	entry := sink.entries[0]
+	if entry.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" ||
+		entry.SpanID != "00f067aa0ba902b7" {
+		t.Fatalf("got wrong trace context: %s, %s", entry.TraceID, entry.SpanID)
+	}
+	if s := entry.Json(); !strings.Contains(s, `"trace.id":"4bf92f3577b34da6a3ce929d0e0e4736"`) {
+		t.Fatalf("got wrong json: %s", s)
+	}
+}